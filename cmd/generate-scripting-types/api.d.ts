@@ -0,0 +1,69 @@
+declare const console: {
+    log(data: any);
+};
+
+declare type WindowID = number;
+declare type Slot = number;
+
+declare type ChunkPos = [number, number];
+declare type BlockPos = [number, number, number];
+
+declare type Block = {
+    name: string;
+    properties: {[k: string]: any};
+};
+
+/** x and z can be in the chunk (0-15) or world coordinates */
+declare type Chunk = {
+    pos: ChunkPos;
+    getBlock(x: number, y: number, z: number, layer?: number): Block | null;
+    setBlock(x: number, y: number, z: number, name: string, properties?: {[k: string]: any}, layer?: number): boolean;
+    blockNBT(x: number, y: number, z: number): {[k: string]: any} | null;
+    biome(x: number, y: number, z: number): string | null;
+    height(x: number, z: number): number;
+};
+
+/** the world in OnSave, changes are written to the saved world */
+declare type SaveWorld = {
+    name: string;
+    dimension: number;
+    chunks(): Array<ChunkPos>;
+    getChunk(x: number, z: number): Chunk | null;
+    entities(): Array<Entity>;
+    removeEntity(uniqueID: number): boolean;
+};
+
+declare type CommandParamType = "int" | "float" | "value" | "target" | "string" | "block" | "position" | "message" | "text" | "json";
+
+declare type CommandParam = {
+    name: string;
+    type: CommandParamType;
+    optional?: boolean;
+};
+
+/**
+ * adds an ingame command, fn gets the words typed after the command,
+ * a returned string is sent to the player as a chat message
+ */
+declare function registerCommand(name: string, params: Array<CommandParam>, fn: (args: Array<string>) => string | void, description?: string): void;
+
+/** key value store that is kept across sessions, in a json file next to the script */
+declare const storage: {
+    get(key: string): any;
+    set(key: string, value: any): void;
+    delete(key: string): void;
+    keys(): Array<string>;
+};
+
+/** loads a script relative to this one, like commonjs. modules share the runtime and storage of the script */
+declare function require(path: string): any;
+declare var module: { exports: any };
+declare var exports: any;
+declare var __filename: string;
+declare var __dirname: string;
+
+declare type EntityMetadata = {
+    [k: EntityDataKey]: any;
+    SetFlag: (key: EntityDataKey, index: EntityDataFlag) => void;
+    Flag: (key: EntityDataKey, index: EntityDataFlag) => boolean;
+};
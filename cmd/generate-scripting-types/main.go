@@ -0,0 +1,493 @@
+// generate-scripting-types writes scripting/bedrocktool.d.ts and the enums.js the scripting vm embeds.
+// the types scripts see are read from the go source of worldstate, gophertunnel and the scripting callbacks,
+// the objects the vm builds by hand (chunks, storage, commands) are in api.d.ts
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+
+	_ "embed"
+)
+
+//go:embed api.d.ts
+var apiDTS string
+
+const (
+	modulePath    = "github.com/bedrock-tool/bedrocktool"
+	protocolPath  = "github.com/sandertv/gophertunnel/minecraft/protocol"
+	worldPath     = "github.com/df-mc/dragonfly/server/world"
+	cubePath      = "github.com/df-mc/dragonfly/server/block/cube"
+	worldstateDir = "handlers/worlds/worldstate"
+	scriptingDir  = "handlers/worlds/scripting"
+
+	DTSFile   = "scripting/bedrocktool.d.ts"
+	EnumsFile = scriptingDir + "/enums.js"
+)
+
+// types that are written in api.d.ts or are renamed for scripts
+var namedTypes = map[string]string{
+	modulePath + "/" + worldstateDir + ".EntityState": "Entity",
+	protocolPath + ".EntityMetadata":                  "EntityMetadata",
+	worldPath + ".ChunkPos":                           "ChunkPos",
+	cubePath + ".Pos":                                 "BlockPos",
+}
+
+// callback parameters that are goja objects or any in go, by name
+var callbackParams = map[string]string{
+	"entity":   "Entity",
+	"metadata": "EntityMetadata",
+	"chunk":    "Chunk",
+	"world":    "SaveWorld",
+}
+
+// the enums copied from gophertunnel, by the prefix of their constants
+var enums = []string{"EntityDataKey", "EntityDataFlag"}
+
+type goPackage struct {
+	path  string
+	types map[string]*ast.TypeSpec
+	files map[string]*ast.File
+	// the file every type is declared in, for resolving imports
+	typeFile map[string]*ast.File
+}
+
+type generator struct {
+	root     string
+	packages map[string]*goPackage
+
+	out     bytes.Buffer
+	emitted map[string]bool
+	queue   []typeRef
+}
+
+type typeRef struct {
+	pkg  *goPackage
+	name string
+}
+
+func packageDir(root, importPath string) (string, error) {
+	cmd := exec.Command("go", "list", "-f", "{{.Dir}}", importPath)
+	cmd.Dir = root
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list %s: %w", importPath, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (g *generator) loadPackage(importPath string) (*goPackage, error) {
+	if p, ok := g.packages[importPath]; ok {
+		return p, nil
+	}
+	var dir string
+	if rel, ok := strings.CutPrefix(importPath, modulePath+"/"); ok {
+		dir = filepath.Join(g.root, rel)
+	} else {
+		var err error
+		dir, err = packageDir(g.root, importPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	p := &goPackage{
+		path:     importPath,
+		types:    make(map[string]*ast.TypeSpec),
+		files:    make(map[string]*ast.File),
+		typeFile: make(map[string]*ast.File),
+	}
+	for _, pkg := range pkgs {
+		for name, f := range pkg.Files {
+			p.files[filepath.Base(name)] = f
+			for _, decl := range f.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok || gd.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gd.Specs {
+					ts := spec.(*ast.TypeSpec)
+					p.types[ts.Name.Name] = ts
+					p.typeFile[ts.Name.Name] = f
+				}
+			}
+		}
+	}
+	g.packages[importPath] = p
+	return p, nil
+}
+
+// importPath finds the package a selector like protocol.ItemStack refers to
+func importPath(f *ast.File, name string) (string, error) {
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if imp.Name != nil {
+			if imp.Name.Name == name {
+				return path, nil
+			}
+			continue
+		}
+		if filepath.Base(path) == name {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no import for %s", name)
+}
+
+func basicType(name string) (string, bool) {
+	switch name {
+	case "bool":
+		return "boolean", true
+	case "string":
+		return "string", true
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte", "float32", "float64":
+		return "number", true
+	case "any":
+		return "any", true
+	}
+	return "", false
+}
+
+// tsType converts a go type expression in file f of pkg to typescript
+func (g *generator) tsType(pkg *goPackage, f *ast.File, expr ast.Expr) (string, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if t, ok := basicType(e.Name); ok {
+			return t, nil
+		}
+		return g.namedType(pkg, e.Name)
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok {
+			return "", fmt.Errorf("unsupported selector %T", e.X)
+		}
+		path, err := importPath(f, x.Name)
+		if err != nil {
+			return "", err
+		}
+		if t, ok := namedTypes[path+"."+e.Sel.Name]; ok {
+			return t, nil
+		}
+		other, err := g.loadPackage(path)
+		if err != nil {
+			return "", err
+		}
+		return g.namedType(other, e.Sel.Name)
+	case *ast.StarExpr:
+		t, err := g.tsType(pkg, f, e.X)
+		if err != nil {
+			return "", err
+		}
+		return t + " | null", nil
+	case *ast.ArrayType:
+		elem, err := g.tsType(pkg, f, e.Elt)
+		if err != nil {
+			return "", err
+		}
+		if e.Len == nil {
+			return "Array<" + elem + ">", nil
+		}
+		lit, ok := e.Len.(*ast.BasicLit)
+		if !ok {
+			return "Array<" + elem + ">", nil
+		}
+		n, err := strconv.Atoi(lit.Value)
+		if err != nil {
+			return "", err
+		}
+		return "[" + strings.TrimSuffix(strings.Repeat(elem+", ", n), ", ") + "]", nil
+	case *ast.MapType:
+		key, err := g.tsType(pkg, f, e.Key)
+		if err != nil {
+			return "", err
+		}
+		if key != "number" {
+			key = "string"
+		}
+		value, err := g.tsType(pkg, f, e.Value)
+		if err != nil {
+			return "", err
+		}
+		return "{[k: " + key + "]: " + value + "}", nil
+	case *ast.InterfaceType:
+		return "any", nil
+	}
+	return "", fmt.Errorf("unsupported type %T", expr)
+}
+
+// namedType returns the name of a struct type and queues it to be declared, other types are inlined
+func (g *generator) namedType(pkg *goPackage, name string) (string, error) {
+	if t, ok := namedTypes[pkg.path+"."+name]; ok {
+		return t, nil
+	}
+	ts, ok := pkg.types[name]
+	if !ok {
+		return "", fmt.Errorf("type %s.%s not found", pkg.path, name)
+	}
+	if _, ok := ts.Type.(*ast.StructType); ok {
+		if !g.emitted[pkg.path+"."+name] {
+			g.emitted[pkg.path+"."+name] = true
+			g.queue = append(g.queue, typeRef{pkg, name})
+		}
+		return name, nil
+	}
+	return g.tsType(pkg, pkg.typeFile[name], ts.Type)
+}
+
+// structFields writes the exported fields, embedded structs are flattened like goja shows them
+func (g *generator) structFields(pkg *goPackage, name string) error {
+	ts := pkg.types[name]
+	f := pkg.typeFile[name]
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		return fmt.Errorf("%s is not a struct", name)
+	}
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			switch e := field.Type.(type) {
+			case *ast.Ident:
+				if err := g.structFields(pkg, e.Name); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unsupported embedded field in %s", name)
+			}
+			continue
+		}
+		t, err := g.tsType(pkg, f, field.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		for _, n := range field.Names {
+			if !n.IsExported() {
+				continue
+			}
+			fmt.Fprintf(&g.out, "    %s: %s;\n", n.Name, t)
+		}
+	}
+	return nil
+}
+
+func (g *generator) writeStructs() error {
+	for len(g.queue) > 0 {
+		ref := g.queue[0]
+		g.queue = g.queue[1:]
+		name := ref.name
+		if t, ok := namedTypes[ref.pkg.path+"."+name]; ok {
+			name = t
+		}
+		fmt.Fprintf(&g.out, "declare type %s = {\n", name)
+		if err := g.structFields(ref.pkg, ref.name); err != nil {
+			return err
+		}
+		g.out.WriteString("};\n\n")
+	}
+	return nil
+}
+
+// writeCallbacks declares the functions scripts can define, from the callbacks struct of the vm
+func (g *generator) writeCallbacks() error {
+	pkg, err := g.loadPackage(modulePath + "/" + scriptingDir)
+	if err != nil {
+		return err
+	}
+	ts, ok := pkg.types["callbacks"]
+	if !ok {
+		return fmt.Errorf("scripting callbacks struct not found")
+	}
+	f := pkg.typeFile["callbacks"]
+
+	g.out.WriteString("/** the functions a script can define, bedrocktool calls them */\n")
+	g.out.WriteString("declare interface Callbacks {\n")
+	for _, field := range ts.Type.(*ast.StructType).Fields.List {
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			continue
+		}
+		var params []string
+		for _, p := range fn.Params.List {
+			for _, n := range p.Names {
+				t, ok := callbackParams[n.Name]
+				if !ok {
+					t, err = g.tsType(pkg, f, p.Type)
+					if err != nil {
+						return fmt.Errorf("callback %s: %w", field.Names[0].Name, err)
+					}
+				}
+				params = append(params, n.Name+": "+t)
+			}
+		}
+		result := "void"
+		if fn.Results != nil && len(fn.Results.List) > 0 {
+			result, err = g.tsType(pkg, f, fn.Results.List[0].Type)
+			if err != nil {
+				return err
+			}
+		}
+		for _, n := range field.Names {
+			fmt.Fprintf(&g.out, "    %s?(%s): %s;\n", n.Name, strings.Join(params, ", "), result)
+		}
+	}
+	g.out.WriteString("}\n\n")
+	return nil
+}
+
+type enumValue struct {
+	Name  string
+	Value int
+}
+
+func constValue(expr ast.Expr, iota int) (int, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if e.Name == "iota" {
+			return iota, nil
+		}
+	case *ast.BasicLit:
+		v, err := strconv.ParseInt(e.Value, 0, 64)
+		return int(v), err
+	case *ast.BinaryExpr:
+		x, err := constValue(e.X, iota)
+		if err != nil {
+			return 0, err
+		}
+		y, err := constValue(e.Y, iota)
+		if err != nil {
+			return 0, err
+		}
+		switch e.Op {
+		case token.ADD:
+			return x + y, nil
+		case token.SUB:
+			return x - y, nil
+		case token.SHL:
+			return x << y, nil
+		}
+	case *ast.ParenExpr:
+		return constValue(e.X, iota)
+	}
+	return 0, fmt.Errorf("unsupported constant %T", expr)
+}
+
+// readEnum collects the constants starting with prefix from the protocol package
+func (g *generator) readEnum(prefix string) ([]enumValue, error) {
+	pkg, err := g.loadPackage(protocolPath)
+	if err != nil {
+		return nil, err
+	}
+	files := maps.Keys(pkg.files)
+	slices.Sort(files)
+	var values []enumValue
+	for _, file := range files {
+		for _, decl := range pkg.files[file].Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST {
+				continue
+			}
+			var last []ast.Expr
+			for iota, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if len(vs.Values) > 0 {
+					last = vs.Values
+				}
+				for i, n := range vs.Names {
+					name, ok := strings.CutPrefix(n.Name, prefix)
+					if !ok || len(last) <= i {
+						continue
+					}
+					v, err := constValue(last[i], iota)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", n.Name, err)
+					}
+					values = append(values, enumValue{name, v})
+				}
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no constants with prefix %s", prefix)
+	}
+	return values, nil
+}
+
+// Generate returns the contents of the d.ts and enums.js, root is the repository root
+func Generate(root string) (dts, enumsJS []byte, err error) {
+	g := &generator{
+		root:     root,
+		packages: make(map[string]*goPackage),
+		emitted:  make(map[string]bool),
+	}
+
+	g.out.WriteString("// Code generated by generate-scripting-types. DO NOT EDIT.\n\n")
+	g.out.WriteString(apiDTS)
+	g.out.WriteString("\n")
+
+	worldstate, err := g.loadPackage(modulePath + "/" + worldstateDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	g.emitted[worldstate.path+".EntityState"] = true
+	g.queue = append(g.queue, typeRef{worldstate, "EntityState"})
+	if err = g.writeStructs(); err != nil {
+		return nil, nil, err
+	}
+	if err = g.writeCallbacks(); err != nil {
+		return nil, nil, err
+	}
+
+	var js bytes.Buffer
+	js.WriteString("// Code generated by generate-scripting-types. DO NOT EDIT.\n")
+	for _, prefix := range enums {
+		values, err := g.readEnum(prefix)
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintf(&g.out, "declare enum %s {\n", prefix)
+		fmt.Fprintf(&js, "var %s;\n(function (%s) {\n", prefix, prefix)
+		for _, v := range values {
+			fmt.Fprintf(&g.out, "    %s = %d,\n", v.Name, v.Value)
+			fmt.Fprintf(&js, "    %s[%s[\"%s\"] = %d] = \"%s\";\n", prefix, prefix, v.Name, v.Value, v.Name)
+		}
+		g.out.WriteString("}\n\n")
+		fmt.Fprintf(&js, "})(%s || (%s = {}));\n", prefix, prefix)
+	}
+
+	return bytes.TrimSuffix(g.out.Bytes(), []byte("\n")), js.Bytes(), nil
+}
+
+func main() {
+	root := flag.String("root", ".", "the bedrocktool repository")
+	flag.Parse()
+
+	dts, enumsJS, err := Generate(*root)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(*root, DTSFile), dts, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(*root, EnumsFile), enumsJS, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
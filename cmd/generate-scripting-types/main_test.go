@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// the checked in typings have to match what the generator writes, run go generate ./handlers/worlds/scripting when this fails
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	root := filepath.Join("..", "..")
+	dts, enumsJS, err := Generate(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for filename, want := range map[string][]byte{
+		DTSFile:   dts,
+		EnumsFile: enumsJS,
+	} {
+		have, err := os.ReadFile(filepath.Join(root, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(have, want) {
+			t.Errorf("%s is stale, run go generate ./handlers/worlds/scripting", filename)
+		}
+	}
+}
@@ -1,22 +1,156 @@
 package worlds
 
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sirupsen/logrus"
+)
+
+// custom biomes the server doesnt give an id get one from here up, above all vanilla biomes
+const customBiomeIDStart = 256
+
 type customBiome struct {
-	name string
-	data map[string]any
+	name        string
+	id          int
+	temperature float64
+	downfall    float64
+	// #rrggbb, empty if the definition has none
+	waterColour   string
+	grassColour   string
+	foliageColour string
+	data          map[string]any
 }
 
 func (c *customBiome) EncodeBiome() int {
-	return 0
+	return c.id
 }
 
 func (c *customBiome) Temperature() float64 {
-	return 0.5
+	return c.temperature
 }
 
 func (c *customBiome) Rainfall() float64 {
-	return 0.5
+	return c.downfall
 }
 
 func (c *customBiome) String() string {
 	return c.name
 }
+
+func biomeFloat(data map[string]any, key string, def float64) float64 {
+	switch v := data[key].(type) {
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return def
+}
+
+// biomeWaterColour reads waterColorR, G and B, the definitions store them as floats from 0 to 1
+func biomeWaterColour(data map[string]any) string {
+	var rgb [3]uint8
+	for i, key := range []string{"waterColorR", "waterColorG", "waterColorB"} {
+		v := biomeFloat(data, key, -1)
+		if v < 0 {
+			return ""
+		}
+		rgb[i] = uint8(min(v, 1) * 255)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])
+}
+
+// biomeColour reads a 0xrrggbb colour, vanilla only sends the water colour but some servers add grass and foliage
+func biomeColour(data map[string]any, key string) string {
+	var c int64
+	switch v := data[key].(type) {
+	case int32:
+		c = int64(v)
+	case int64:
+		c = v
+	default:
+		return ""
+	}
+	return fmt.Sprintf("#%06x", c&0xffffff)
+}
+
+// biomeID is the id the server uses for a biome in chunks, if it sends it
+func biomeID(data map[string]any) (int, bool) {
+	switch v := data["id"].(type) {
+	case uint8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// registerCustomBiomes adds the biomes from BiomeDefinitionList that the registry doesnt know,
+// the id each one got is put in ids. ids are allocated in name order so the same server always gets the same ids
+func registerCustomBiomes(biomes *world.BiomeRegistry, definitions map[string]any, ids map[string]int) []behaviourpack.BiomeIn {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		if _, ok := biomes.BiomeByName(name); !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var added []behaviourpack.BiomeIn
+	nextID := customBiomeIDStart
+	for _, name := range names {
+		data, _ := definitions[name].(map[string]any)
+		id, ok := biomeID(data)
+		if _, taken := biomes.BiomeByID(id); !ok || taken {
+			for {
+				if _, taken := biomes.BiomeByID(nextID); !taken {
+					break
+				}
+				nextID++
+			}
+			if ok {
+				logrus.Warnf("biome %s has id %d which is already used, using %d", name, id, nextID)
+			}
+			id = nextID
+		}
+
+		b := &customBiome{
+			name:          name,
+			id:            id,
+			temperature:   biomeFloat(data, "temperature", 0.5),
+			downfall:      biomeFloat(data, "downfall", 0.5),
+			waterColour:   biomeWaterColour(data),
+			grassColour:   biomeColour(data, "grassColor"),
+			foliageColour: biomeColour(data, "foliageColor"),
+			data:          data,
+		}
+		biomes.Register(b)
+		ids[name] = id
+
+		var tags []string
+		if list, ok := data["tags"].([]any); ok {
+			for _, tag := range list {
+				if tag, ok := tag.(string); ok {
+					tags = append(tags, tag)
+				}
+			}
+		}
+		added = append(added, behaviourpack.BiomeIn{
+			Identifier:    name,
+			Temperature:   b.temperature,
+			Downfall:      b.downfall,
+			Tags:          tags,
+			WaterColour:   b.waterColour,
+			GrassColour:   b.grassColour,
+			FoliageColour: b.foliageColour,
+		})
+	}
+	return added
+}
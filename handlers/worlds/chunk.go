@@ -9,7 +9,6 @@ import (
 	"github.com/df-mc/dragonfly/server/block/cube"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/df-mc/dragonfly/server/world/chunk"
-	"github.com/gregwebs/go-recovery"
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
@@ -21,6 +20,14 @@ func (w *worldsHandler) processChangeDimension(pk *packet.ChangeDimension) {
 	w.SaveAndReset(false, dim)
 }
 
+// indexBlockNBTs adds the text of block entities sent with a chunk to the text index
+func (w *worldsHandler) indexBlockNBTs(blockNBTs map[cube.Pos]worldstate.DummyBlock) {
+	dim := w.currentWorld.Dimension()
+	for pos, b := range blockNBTs {
+		w.currentWorld.TextIndex.SetBlock(dim, pos, b.NBT)
+	}
+}
+
 func (w *worldsHandler) processLevelChunk(pk *packet.LevelChunk) {
 	if len(pk.RawPayload) == 0 {
 		logrus.Info(locale.Loc("empty_chunk", nil))
@@ -50,6 +57,9 @@ func (w *worldsHandler) processLevelChunk(pk *packet.LevelChunk) {
 		x := int(blockNBT["x"].(int32))
 		y := int(blockNBT["y"].(int32))
 		z := int(blockNBT["z"].(int32))
+		if w.scriptBlockNBT(cube.Pos{x, y, z}, blockNBT) {
+			continue
+		}
 		chunkBlockNBT[cube.Pos{x, y, z}] = worldstate.DummyBlock{
 			ID:  blockNBT["id"].(string),
 			NBT: blockNBT,
@@ -57,23 +67,14 @@ func (w *worldsHandler) processLevelChunk(pk *packet.LevelChunk) {
 	}
 
 	pos := world.ChunkPos(pk.Position)
-	if w.scripting.CB.OnChunkAdd != nil {
-		var ignore bool
-		err := recovery.Call(func() error {
-			ignore = w.scripting.CB.OnChunkAdd(pos)
-			return nil
-		})
-		if err != nil {
-			logrus.Errorf("Scripting: %s", err)
-		}
-		if ignore {
-			return
-		}
+	if w.scriptChunkAdd(pos, ch, chunkBlockNBT) {
+		return
 	}
 	err = w.currentWorld.StoreChunk(pos, ch, chunkBlockNBT)
 	if err != nil {
 		logrus.Error(err)
 	}
+	w.indexBlockNBTs(chunkBlockNBT)
 
 	max := w.currentWorld.Dimension().Range().Height() / 16
 	switch pk.SubChunkCount {
@@ -182,11 +183,15 @@ func (w *worldsHandler) processSubChunk(pk *packet.SubChunk) error {
 					if err = dec.Decode(&blockNBT); err != nil {
 						return err
 					}
-					blockNBTs[pos][cube.Pos{
+					p := cube.Pos{
 						int(blockNBT["x"].(int32)),
 						int(blockNBT["y"].(int32)),
 						int(blockNBT["z"].(int32)),
-					}] = worldstate.DummyBlock{
+					}
+					if w.scriptBlockNBT(p, blockNBT) {
+						continue
+					}
+					blockNBTs[pos][p] = worldstate.DummyBlock{
 						ID:  blockNBT["id"].(string),
 						NBT: blockNBT,
 					}
@@ -196,7 +201,12 @@ func (w *worldsHandler) processSubChunk(pk *packet.SubChunk) error {
 	}
 
 	for cp, c := range chunks {
+		if w.scriptChunkAdd(cp, c, blockNBTs[cp]) {
+			w.currentWorld.DropChunk(cp)
+			continue
+		}
 		w.currentWorld.StoreChunk(cp, c, blockNBTs[cp])
+		w.indexBlockNBTs(blockNBTs[cp])
 		w.mapUI.SetChunk(cp, c, w.currentWorld.IsPaused())
 	}
 
@@ -0,0 +1,180 @@
+package worlds
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"time"
+
+	"github.com/bedrock-tool/bedrocktool/handlers/worlds/worldstate"
+	"github.com/bedrock-tool/bedrocktool/utils/textindex"
+	"github.com/bedrock-tool/bedrocktool/utils/worldmeta"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DedupeOff  = "off"
+	DedupeSkip = "skip"
+	DedupeLink = "link"
+)
+
+const fingerprintsFile = "fingerprints.json"
+
+type duplicateWorld struct {
+	Name        string    `json:"name"`
+	Of          string    `json:"of"`
+	Fingerprint string    `json:"fingerprint"`
+	Time        time.Time `json:"time"`
+}
+
+// fingerprintManifest is stored in worlds/<server>/ and keeps track of which world has which content
+type fingerprintManifest struct {
+	Worlds     map[string]string `json:"worlds"` // fingerprint -> world name
+	Duplicates []duplicateWorld  `json:"duplicates"`
+}
+
+func readFingerprintManifest(filename string) (*fingerprintManifest, error) {
+	m := &fingerprintManifest{
+		Worlds: make(map[string]string),
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return m, nil
+		}
+		return nil, err
+	}
+	err = json.Unmarshal(data, m)
+	if err != nil {
+		return nil, err
+	}
+	if m.Worlds == nil {
+		m.Worlds = make(map[string]string)
+	}
+	return m, nil
+}
+
+func (m *fingerprintManifest) write(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	e := json.NewEncoder(f)
+	e.SetIndent("", "\t")
+	return e.Encode(m)
+}
+
+// checkDuplicate records the fingerprint of the world in the manifest,
+// returns the name of an earlier world with the same content if there is one
+func (w *worldsHandler) checkDuplicate(worldState *worldstate.World) (string, error) {
+	if worldState.Fingerprint == "" {
+		return "", nil
+	}
+	w.fingerprintLock.Lock()
+	defer w.fingerprintLock.Unlock()
+
+	manifestPath := path.Join(path.Dir(worldState.Folder), fingerprintsFile)
+	manifest, err := readFingerprintManifest(manifestPath)
+	if err != nil {
+		return "", err
+	}
+
+	original, ok := manifest.Worlds[worldState.Fingerprint]
+	if ok && original != worldState.Name {
+		// the earlier world might still be zipping
+		w.waitSaved(original)
+		// only count it when the earlier world is still there
+		_, err := os.Stat(path.Join(path.Dir(worldState.Folder), original+".mcworld"))
+		if err == nil {
+			manifest.Duplicates = append(manifest.Duplicates, duplicateWorld{
+				Name:        worldState.Name,
+				Of:          original,
+				Fingerprint: worldState.Fingerprint,
+				Time:        time.Now(),
+			})
+			return original, manifest.write(manifestPath)
+		}
+	}
+
+	manifest.Worlds[worldState.Fingerprint] = worldState.Name
+	return "", manifest.write(manifestPath)
+}
+
+// dedupeWorld skips or links the world if its identical to an earlier one, returns true if it was handled
+func (w *worldsHandler) dedupeWorld(worldState *worldstate.World, filename string) bool {
+	if w.settings.Dedupe == DedupeOff || w.settings.Dedupe == "" {
+		return false
+	}
+
+	original, err := w.checkDuplicate(worldState)
+	if err != nil {
+		logrus.Errorf("dedupe: %s", err)
+		return false
+	}
+	if original == "" {
+		return false
+	}
+
+	switch w.settings.Dedupe {
+	case DedupeSkip:
+		logrus.Infof("%s is identical to %s, skipping", worldState.Name, original)
+	case DedupeLink:
+		originalFile := path.Join(path.Dir(worldState.Folder), original+".mcworld")
+		os.Remove(filename)
+		err = os.Link(originalFile, filename)
+		if err != nil {
+			logrus.Errorf("dedupe: %s", err)
+			return false
+		}
+		logrus.Infof("%s is identical to %s, linked", worldState.Name, original)
+	default:
+		logrus.Warnf("unknown dedupe mode %s", w.settings.Dedupe)
+		return false
+	}
+
+	keepSidecars(worldState.Folder)
+	os.RemoveAll(worldState.Folder)
+	if w.settings.Dedupe == DedupeLink {
+		w.proxy.SendMessage("Linked duplicate world " + worldState.Name + " to " + original)
+	} else {
+		w.proxy.SendMessage("Skipped duplicate world " + worldState.Name)
+	}
+	return true
+}
+
+// keepSidecars moves the text index and metadata out of a world folder that gets removed,
+// they are kept next to it as <world>.<file>
+func keepSidecars(folder string) {
+	for _, name := range []string{textindex.Filename, worldmeta.Filename} {
+		err := os.Rename(path.Join(folder, name), folder+"."+name)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			logrus.Errorf("dedupe: %s", err)
+		}
+	}
+}
+
+// startSaving marks the world as being saved until the returned func is called
+func (w *worldsHandler) startSaving(name string) func() {
+	done := make(chan struct{})
+	w.savingLock.Lock()
+	w.saving[name] = done
+	w.savingLock.Unlock()
+	return func() {
+		w.savingLock.Lock()
+		delete(w.saving, name)
+		w.savingLock.Unlock()
+		close(done)
+	}
+}
+
+// waitSaved blocks until the world is done saving, returns right away if it isnt being saved
+func (w *worldsHandler) waitSaved(name string) {
+	w.savingLock.Lock()
+	done, ok := w.saving[name]
+	w.savingLock.Unlock()
+	if ok {
+		<-done
+	}
+}
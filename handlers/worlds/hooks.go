@@ -0,0 +1,53 @@
+package worlds
+
+import (
+	"github.com/bedrock-tool/bedrocktool/handlers/worlds/worldstate"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/sirupsen/logrus"
+)
+
+// scriptSaveWorld is the world state as OnSave sees it
+type scriptSaveWorld struct {
+	*worldstate.World
+}
+
+func (s scriptSaveWorld) EntityList() []any {
+	entities := s.World.EntityList()
+	out := make([]any, len(entities))
+	for i, e := range entities {
+		out[i] = e
+	}
+	return out
+}
+
+// scriptChunkAdd calls OnChunkAdd, true means the chunk should not be saved
+func (w *worldsHandler) scriptChunkAdd(pos world.ChunkPos, ch *chunk.Chunk, blockNBTs map[cube.Pos]worldstate.DummyBlock) bool {
+	nbts := make(map[cube.Pos]map[string]any, len(blockNBTs))
+	for p, b := range blockNBTs {
+		nbts[p] = b.NBT
+	}
+	ignore, err := w.scripting.OnChunkAdd(pos, ch, nbts, w.serverState.biomes)
+	if err != nil {
+		logrus.Errorf("Scripting: %s", err)
+	}
+	return ignore
+}
+
+// scriptBlockNBT calls OnBlockNBT, the script can change the nbt, true means it should not be saved
+func (w *worldsHandler) scriptBlockNBT(pos cube.Pos, nbt map[string]any) bool {
+	ignore, err := w.scripting.OnBlockNBT(pos, nbt)
+	if err != nil {
+		logrus.Errorf("Scripting: %s", err)
+	}
+	return ignore
+}
+
+// scriptSave calls OnSave before the world is written
+func (w *worldsHandler) scriptSave(worldState *worldstate.World) {
+	err := w.scripting.OnSave(worldState.Name, worldState.Dimension(), scriptSaveWorld{worldState}, w.serverState.biomes)
+	if err != nil {
+		logrus.Errorf("Scripting: %s", err)
+	}
+}
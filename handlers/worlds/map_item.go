@@ -74,6 +74,7 @@ type MapUI struct {
 	oldRendered    map[protocol.ChunkPos]*image.RGBA
 	ticker         *time.Ticker
 	w              *worldsHandler
+	web            *webMap
 
 	l  sync.Mutex
 	wg sync.WaitGroup
@@ -123,6 +124,14 @@ func (m *MapUI) Start(ctx context.Context) {
 		return
 	}
 
+	if m.w.settings.WebMap != "" && m.web == nil {
+		m.web = newWebMap(m, m.w.settings.WebMap)
+		if err := m.web.Start(m.w.ctx); err != nil {
+			logrus.Errorf("Web map: %s", err)
+			m.web = nil
+		}
+	}
+
 	m.ticker = time.NewTicker(33 * time.Millisecond)
 	m.wg.Add(1)
 	go func() {
@@ -145,6 +154,9 @@ func (m *MapUI) Start(ctx context.Context) {
 				m.needRedraw = true
 				oldPos = newPos
 			}
+			if m.web != nil {
+				m.web.UpdatePlayer(newPos, m.w.proxy.Player.Yaw)
+			}
 
 			if m.needRedraw {
 				m.needRedraw = false
@@ -197,6 +209,9 @@ func (m *MapUI) Reset() {
 			ChunkCount: -1,
 		},
 	})
+	if m.web != nil {
+		m.web.Reset()
+	}
 	m.l.Unlock()
 	m.SchedRedraw()
 }
@@ -242,6 +257,7 @@ func (m *MapUI) processQueue() []protocol.ChunkPos {
 			} else {
 				delete(m.renderedChunks, r.pos)
 			}
+			updatedChunks = append(updatedChunks, r.pos)
 		}
 	}
 	return updatedChunks
@@ -278,6 +294,10 @@ func (m *MapUI) redraw() {
 		}
 	}
 
+	if m.web != nil {
+		m.web.UpdateChunks(updatedChunks)
+	}
+
 	// send tiles to gui map
 	if m.showOnGui {
 		messages.Router.Handle(&messages.Message{
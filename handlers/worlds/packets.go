@@ -3,6 +3,7 @@ package worlds
 import (
 	"image"
 	"maps"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,7 +18,6 @@ import (
 	"github.com/df-mc/dragonfly/server/item/inventory"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/go-gl/mathgl/mgl32"
-	"github.com/gregwebs/go-recovery"
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
@@ -51,6 +51,8 @@ func (w *worldsHandler) packetCB(_pk packet.Packet, toServer bool, timeReceived
 	case *packet.StartGame:
 		if !w.serverState.haveStartGame {
 			w.serverState.haveStartGame = true
+			w.serverState.startGame = pk
+			w.serverState.gameRules = slices.Clone(pk.GameRules)
 			w.currentWorld.SetTime(timeReceived, int(pk.Time))
 			w.serverState.useHashedRids = pk.UseBlockNetworkIDHashes
 
@@ -104,6 +106,26 @@ func (w *worldsHandler) packetCB(_pk packet.Packet, toServer bool, timeReceived
 			w.openWorldState(w.settings.StartPaused)
 		}
 
+	case *packet.GameRulesChanged:
+		for _, gr := range pk.GameRules {
+			idx := slices.IndexFunc(w.serverState.gameRules, func(r protocol.GameRule) bool {
+				return r.Name == gr.Name
+			})
+			if idx >= 0 {
+				w.serverState.gameRules[idx] = gr
+			} else {
+				w.serverState.gameRules = append(w.serverState.gameRules, gr)
+			}
+		}
+
+	case *packet.LevelEvent:
+		switch pk.EventType {
+		case packet.LevelEventStartRaining, packet.LevelEventStopRaining:
+			w.currentWorld.SetWeather(false, pk.EventData)
+		case packet.LevelEventStartThunderstorm, packet.LevelEventStopThunderstorm:
+			w.currentWorld.SetWeather(true, pk.EventData)
+		}
+
 	case *packet.DimensionData:
 		for _, dd := range pk.Definitions {
 			if dd.Name == "minecraft:overworld" {
@@ -114,6 +136,27 @@ func (w *worldsHandler) packetCB(_pk packet.Packet, toServer bool, timeReceived
 	case *packet.ItemComponent:
 		w.bp.ApplyComponentEntries(pk.Items)
 
+	case *packet.CraftingData:
+		for _, recipe := range pk.Recipes {
+			w.bp.AddRecipe(recipe)
+		}
+
+	case *packet.CreativeContent:
+		w.serverState.creativeItems = pk.Items
+
+	case *packet.AvailableCommands:
+		// copy it, the proxy adds its own commands to the packet after this
+		commands := *pk
+		commands.EnumValues = slices.Clone(pk.EnumValues)
+		commands.ChainedSubcommandValues = slices.Clone(pk.ChainedSubcommandValues)
+		commands.Suffixes = slices.Clone(pk.Suffixes)
+		commands.Enums = slices.Clone(pk.Enums)
+		commands.ChainedSubcommands = slices.Clone(pk.ChainedSubcommands)
+		commands.Commands = slices.Clone(pk.Commands)
+		commands.DynamicEnums = slices.Clone(pk.DynamicEnums)
+		commands.Constraints = slices.Clone(pk.Constraints)
+		w.serverState.commands = &commands
+
 	case *packet.BiomeDefinitionList:
 		var biomes map[string]any
 		err := nbt.UnmarshalEncoding(pk.SerialisedBiomeDefinitions, &biomes, nbt.NetworkLittleEndian)
@@ -121,16 +164,9 @@ func (w *worldsHandler) packetCB(_pk packet.Packet, toServer bool, timeReceived
 			logrus.Error(err)
 		}
 
-		for k, v := range biomes {
-			_, ok := w.serverState.biomes.BiomeByName(k)
-			if !ok {
-				w.serverState.biomes.Register(&customBiome{
-					name: k,
-					data: v.(map[string]any),
-				})
-			}
+		for _, biome := range registerCustomBiomes(w.serverState.biomes, biomes, w.serverState.customBiomes) {
+			w.bp.AddBiome(biome)
 		}
-		w.bp.AddBiomes(biomes)
 	}
 
 	_pk = w.itemPackets(_pk)
@@ -217,6 +253,12 @@ func (w *worldsHandler) playersPackets(_pk packet.Packet) {
 		}
 	case *packet.PlayerSkin:
 		w.serverState.playerSkins[pk.UUID] = &pk.Skin
+	case *packet.MovePlayer:
+		w.currentWorld.MovePlayer(pk.EntityRuntimeID, pk.Position, pk.Pitch, pk.Yaw, pk.HeadYaw)
+	case *packet.MobEquipment:
+		w.currentWorld.PlayerEquipment(pk)
+	case *packet.MobArmourEquipment:
+		w.currentWorld.PlayerArmour(pk)
 	}
 }
 
@@ -224,15 +266,9 @@ func (w *worldsHandler) entityPackets(_pk packet.Packet) {
 	switch pk := _pk.(type) {
 	case *packet.AddActor:
 		w.currentWorld.ProcessAddActor(pk, func(es *worldstate.EntityState) bool {
-			var ignore bool
-			if w.scripting.CB.OnEntityAdd != nil {
-				err := recovery.Call(func() error {
-					ignore = w.scripting.OnEntityAdd(es, es.Metadata)
-					return nil
-				})
-				if err != nil {
-					logrus.Errorf("scripting: %s", err)
-				}
+			ignore, err := w.scripting.OnEntityAdd(es, es.Metadata)
+			if err != nil {
+				logrus.Errorf("Scripting: %s", err)
 			}
 			return ignore
 		}, w.bp.AddEntity)
@@ -241,14 +277,8 @@ func (w *worldsHandler) entityPackets(_pk packet.Packet) {
 		if e := w.getEntity(pk.EntityRuntimeID); e != nil {
 			metadata := make(protocol.EntityMetadata)
 			maps.Copy(metadata, pk.EntityMetadata)
-			if w.scripting.CB.OnEntityDataUpdate != nil {
-				err := recovery.Call(func() error {
-					w.scripting.OnEntityDataUpdate(e, metadata)
-					return nil
-				})
-				if err != nil {
-					logrus.Errorf("Scripting %s", err)
-				}
+			if err := w.scripting.OnEntityDataUpdate(e, metadata); err != nil {
+				logrus.Errorf("Scripting: %s", err)
 			}
 
 			maps.Copy(e.Metadata, metadata)
@@ -262,6 +292,7 @@ func (w *worldsHandler) entityPackets(_pk packet.Packet) {
 	case *packet.SetActorMotion:
 		if e := w.getEntity(pk.EntityRuntimeID); e != nil {
 			e.Velocity = pk.Velocity
+			w.currentWorld.RecordMove(e)
 		}
 
 	case *packet.MoveActorDelta:
@@ -284,8 +315,12 @@ func (w *worldsHandler) entityPackets(_pk packet.Packet) {
 			if !e.Velocity.ApproxEqual(mgl32.Vec3{}) {
 				e.HasMoved = true
 			}
+			w.currentWorld.RecordMove(e)
 		}
 
+	case *packet.RemoveActor:
+		w.currentWorld.RemoveActor(pk.EntityUniqueID)
+
 	case *packet.MoveActorAbsolute:
 		if e := w.getEntity(pk.EntityRuntimeID); e != nil {
 			e.Position = pk.Position
@@ -294,6 +329,7 @@ func (w *worldsHandler) entityPackets(_pk packet.Packet) {
 			if !e.Velocity.ApproxEqual(mgl32.Vec3{}) {
 				e.HasMoved = true
 			}
+			w.currentWorld.RecordMove(e)
 		}
 
 	case *packet.MobEquipment:
@@ -304,18 +340,36 @@ func (w *worldsHandler) entityPackets(_pk packet.Packet) {
 				e.Inventory[pk.WindowID] = w
 			}
 			w[pk.HotBarSlot] = pk.NewItem
+
+			item := pk.NewItem
+			if pk.WindowID == protocol.WindowIDOffHand {
+				e.Offhand = &item
+			} else {
+				e.Mainhand = &item
+			}
 		}
 
 	case *packet.MobArmourEquipment:
 		if e := w.getEntity(pk.EntityRuntimeID); e != nil {
 			e.Helmet = &pk.Helmet
 			e.Chestplate = &pk.Chestplate
-			e.Leggings = &pk.Chestplate
+			e.Leggings = &pk.Leggings
 			e.Boots = &pk.Boots
 		}
 
 	case *packet.SetActorLink:
 		w.currentWorld.AddEntityLink(pk.EntityLink)
+
+	case *packet.UpdateTrade:
+		if e := w.currentWorld.GetEntityByUniqueID(pk.VillagerUniqueID); e != nil {
+			var offers map[string]any
+			err := nbt.UnmarshalEncoding(pk.SerialisedOffers, &offers, nbt.NetworkLittleEndian)
+			if err != nil {
+				logrus.Error(err)
+				break
+			}
+			e.Offers = offers
+		}
 	}
 }
 
@@ -336,7 +390,11 @@ func (w *worldsHandler) chunkPackets(_pk packet.Packet) {
 	case *packet.BlockActorData:
 		p := pk.Position
 		pos := cube.Pos{int(p.X()), int(p.Y()), int(p.Z())}
+		if w.scriptBlockNBT(pos, pk.NBTData) {
+			break
+		}
 		w.currentWorld.SetBlockNBT(pos, pk.NBTData, false)
+		w.currentWorld.TextIndex.SetBlock(w.currentWorld.Dimension(), pos, pk.NBTData)
 		/*
 			case *packet.UpdateBlock:
 				if w.settings.BlockUpdates {
@@ -493,6 +551,22 @@ func (w *worldsHandler) itemPackets(_pk packet.Packet) packet.Packet {
 				break
 			}
 
+			// entity inventory, chested mobs
+			if existing.OpenPacket.ContainerEntityUniqueID != -1 {
+				if e := w.currentWorld.GetEntityByUniqueID(existing.OpenPacket.ContainerEntityUniqueID); e != nil {
+					e.ChestItems = existing.Content.Content
+					items := make([]map[string]any, 0, len(e.ChestItems))
+					for _, c := range e.ChestItems {
+						items = append(items, nbtconv.WriteItem(utils.StackToItem(w.serverState.blocks, c.Stack), true))
+					}
+					pos := cube.Pos{int(e.Position.X()), int(e.Position.Y()), int(e.Position.Z())}
+					w.currentWorld.TextIndex.SetContainer(w.currentWorld.Dimension(), pos, items)
+					w.proxy.SendMessage(locale.Loc("saved_block_inv", nil))
+				}
+				delete(w.serverState.openItemContainers, byte(pk.WindowID))
+				break
+			}
+
 			// create inventory
 			inv := inventory.New(len(existing.Content.Content), nil)
 			for i, c := range existing.Content.Content {
@@ -503,9 +577,11 @@ func (w *worldsHandler) itemPackets(_pk packet.Packet) packet.Packet {
 			// put into subchunk
 			p := existing.OpenPacket.ContainerPosition
 			pos := cube.Pos{int(p.X()), int(p.Y()), int(p.Z())}
+			items := nbtconv.InvToNBT(inv)
 			w.currentWorld.SetBlockNBT(pos, map[string]any{
-				"Items": nbtconv.InvToNBT(inv),
+				"Items": items,
 			}, true)
+			w.currentWorld.TextIndex.SetContainer(w.currentWorld.Dimension(), pos, items)
 
 			w.proxy.SendMessage(locale.Loc("saved_block_inv", nil))
 
@@ -9,6 +9,7 @@ import (
 
 	"github.com/bedrock-tool/bedrocktool/locale"
 	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/bedrock-tool/bedrocktool/utils/resourcepack"
 	"github.com/flytam/filenamify"
 	"github.com/sirupsen/logrus"
 )
@@ -32,7 +33,7 @@ func (w *worldsHandler) AddPacks(folder string) {
 	}
 
 	// save behaviourpack
-	if w.bp.HasContent() {
+	if w.bp.HasContent() || (w.settings.Players && w.bp.HasEntities()) {
 		name := strings.ReplaceAll(w.serverState.Name, "./", "")
 		name = strings.ReplaceAll(name, "/", "-")
 		name = strings.ReplaceAll(name, ":", "_")
@@ -87,6 +88,32 @@ func (w *worldsHandler) AddPacks(folder string) {
 			})
 		}
 
+		// icons and attachables of the custom items, in case the server packs miss them
+		if w.bp.HasItems() {
+			itemsRP := resourcepack.NewItems(w.serverState.Name)
+			if itemsRP.AddItems(w.bp.ItemIcons(), w.serverState.packs) {
+				btItemsFolder := path.Join(folder, "resource_packs", "bt_items")
+				os.MkdirAll(btItemsFolder, 0755)
+				itemsRP.WriteToDir(btItemsFolder)
+				rdeps = append(rdeps, dep{
+					PackID:  itemsRP.Manifest.Header.UUID,
+					Version: itemsRP.Manifest.Header.Version,
+				})
+			}
+		}
+
+		// water colours of the custom biomes
+		if waterColours := w.bp.BiomeWaterColours(); len(waterColours) > 0 {
+			biomesRP := resourcepack.NewBiomes(w.serverState.Name, waterColours)
+			btBiomesFolder := path.Join(folder, "resource_packs", "bt_biomes")
+			os.MkdirAll(btBiomesFolder, 0755)
+			biomesRP.WriteToDir(btBiomesFolder)
+			rdeps = append(rdeps, dep{
+				PackID:  biomesRP.Manifest.Header.UUID,
+				Version: biomesRP.Manifest.Header.Version,
+			})
+		}
+
 		if len(w.rp.Files) > 0 && w.settings.Players {
 			btPlayersFolder := path.Join(folder, "resource_packs", "bt_players")
 			os.MkdirAll(btPlayersFolder, 0755)
@@ -0,0 +1,111 @@
+package scripting
+
+import (
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/dop251/goja"
+)
+
+// SaveWorld is the world that OnSave can change before it is written
+type SaveWorld interface {
+	ChunkPositions() []world.ChunkPos
+	LoadChunk(pos world.ChunkPos) (*chunk.Chunk, bool, error)
+	ChunkBlockNBTs(pos world.ChunkPos) map[cube.Pos]map[string]any
+	EntityList() []any
+	RemoveEntity(uniqueID int64) bool
+}
+
+// blockProperties converts the numbers scripts pass to the int32 block states use
+func blockProperties(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		switch v := v.(type) {
+		case int64:
+			out[k] = int32(v)
+		case float64:
+			out[k] = int32(v)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// chunkObject makes the object scripts use to read and change a chunk,
+// x and z can be in the chunk or world coordinates, only the lower 4 bits are used
+func (s *script) chunkObject(pos world.ChunkPos, ch *chunk.Chunk, nbts map[cube.Pos]map[string]any, biomes *world.BiomeRegistry) *goja.Object {
+	br := ch.BlockRegistry.(world.BlockRegistry)
+	r := ch.Range()
+	worldPos := func(x, y, z int) cube.Pos {
+		return cube.Pos{int(pos[0])<<4 + x&15, y, int(pos[1])<<4 + z&15}
+	}
+
+	obj := s.vm.NewObject()
+	obj.Set("pos", pos)
+	obj.Set("getBlock", func(x, y, z, layer int) any {
+		if y < r.Min() || y > r.Max() {
+			return nil
+		}
+		b, found := br.BlockByRuntimeID(ch.Block(uint8(x&15), int16(y), uint8(z&15), uint8(layer)))
+		if !found {
+			return nil
+		}
+		name, properties := b.EncodeBlock()
+		return map[string]any{"name": name, "properties": properties}
+	})
+	obj.Set("setBlock", func(x, y, z int, name string, properties map[string]any, layer int) bool {
+		if y < r.Min() || y > r.Max() {
+			return false
+		}
+		b, found := br.BlockByName(name, blockProperties(properties))
+		if !found {
+			return false
+		}
+		ch.SetBlock(uint8(x&15), int16(y), uint8(z&15), uint8(layer), br.BlockRuntimeID(b))
+		return true
+	})
+	obj.Set("blockNBT", func(x, y, z int) any {
+		if nbt, ok := nbts[worldPos(x, y, z)]; ok {
+			return nbt
+		}
+		return nil
+	})
+	obj.Set("biome", func(x, y, z int) any {
+		if y < r.Min() || y > r.Max() {
+			return nil
+		}
+		b, ok := biomes.BiomeByID(int(ch.Biome(uint8(x&15), int16(y), uint8(z&15))))
+		if !ok {
+			return nil
+		}
+		return b.String()
+	})
+	obj.Set("height", func(x, z int) int {
+		return int(ch.HighestBlock(uint8(x&15), uint8(z&15)))
+	})
+	return obj
+}
+
+// worldObject makes the object OnSave gets
+func (s *script) worldObject(name string, dim world.Dimension, w SaveWorld, biomes *world.BiomeRegistry) *goja.Object {
+	obj := s.vm.NewObject()
+	obj.Set("name", name)
+	dimID, _ := world.DimensionID(dim)
+	obj.Set("dimension", dimID)
+	obj.Set("chunks", w.ChunkPositions)
+	obj.Set("getChunk", func(x, z int32) any {
+		pos := world.ChunkPos{x, z}
+		ch, ok, err := w.LoadChunk(pos)
+		if err != nil {
+			panic(s.vm.NewGoError(err))
+		}
+		if !ok {
+			return nil
+		}
+		return s.chunkObject(pos, ch, w.ChunkBlockNBTs(pos), biomes)
+	})
+	obj.Set("entities", w.EntityList)
+	obj.Set("removeEntity", w.RemoveEntity)
+	return obj
+}
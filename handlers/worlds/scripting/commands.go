@@ -0,0 +1,95 @@
+package scripting
+
+import (
+	"fmt"
+
+	"github.com/dop251/goja"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// the parameter types scripts can use in registerCommand
+var commandParamTypes = map[string]uint32{
+	"int":      protocol.CommandArgTypeInt,
+	"float":    protocol.CommandArgTypeFloat,
+	"value":    protocol.CommandArgTypeValue,
+	"target":   protocol.CommandArgTypeTarget,
+	"string":   protocol.CommandArgTypeString,
+	"block":    protocol.CommandArgTypeBlockPosition,
+	"position": protocol.CommandArgTypePosition,
+	"message":  protocol.CommandArgTypeMessage,
+	"text":     protocol.CommandArgTypeRawText,
+	"json":     protocol.CommandArgTypeJSON,
+}
+
+// registerCommand is the registerCommand(name, params, fn, description) function of scripts
+func (s *script) registerCommand(name string, params []map[string]any, fn goja.Callable, description string) {
+	v := s.v
+	if v.AddCommand == nil {
+		panic(s.vm.NewGoError(fmt.Errorf("commands are not available")))
+	}
+	if fn == nil {
+		panic(s.vm.NewTypeError("registerCommand: fn is not a function"))
+	}
+
+	var parameters []protocol.CommandParameter
+	for _, p := range params {
+		paramName, _ := p["name"].(string)
+		paramType, _ := p["type"].(string)
+		optional, _ := p["optional"].(bool)
+		t, ok := commandParamTypes[paramType]
+		if !ok {
+			panic(s.vm.NewTypeError("registerCommand: unknown parameter type %q", paramType))
+		}
+		parameters = append(parameters, protocol.CommandParameter{
+			Name:     paramName,
+			Type:     protocol.CommandArgValid | t,
+			Optional: optional,
+		})
+	}
+	if description == "" {
+		description = "script command"
+	}
+
+	// commands stay registered in the proxy after a reload, they only run while their runtime is the current one
+	runtime := s.vm
+	v.AddCommand(func(args []string) bool {
+		v.l.Lock()
+		if s.vm != runtime {
+			v.l.Unlock()
+			if v.SendMessage != nil {
+				v.SendMessage(fmt.Sprintf("/%s was removed when the script reloaded", name))
+			}
+			return true
+		}
+		var msg string
+		err := s.call("/"+name, v.Limits.Timeout, func() error {
+			ret, err := fn(goja.Undefined(), s.vm.ToValue(args))
+			if err != nil {
+				return err
+			}
+			if ret != nil && !goja.IsUndefined(ret) && !goja.IsNull(ret) {
+				msg = ret.String()
+			}
+			return nil
+		})
+		v.l.Unlock()
+		if err != nil {
+			logrus.Errorf("Scripting: %s", err)
+			if v.SendMessage != nil {
+				v.SendMessage(fmt.Sprintf("/%s failed: %s", name, err))
+			}
+			return true
+		}
+		if msg != "" && v.SendMessage != nil {
+			v.SendMessage(msg)
+		}
+		return true
+	}, protocol.Command{
+		Name:        name,
+		Description: description,
+		Overloads: []protocol.CommandOverload{{
+			Parameters: parameters,
+		}},
+	})
+}
@@ -0,0 +1,166 @@
+package scripting
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime/metrics"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/gregwebs/go-recovery"
+)
+
+var (
+	errTimeout     = errors.New("timeout")
+	errMemoryLimit = errors.New("memory limit")
+)
+
+// Limits are what a single script callback is allowed to use
+type Limits struct {
+	// how long one callback can run before it is interrupted
+	Timeout time.Duration
+	// OnSave goes over the whole world so it gets longer
+	SaveTimeout time.Duration
+	// how much the heap can grow while a callback runs, 0 is unlimited.
+	// this is measured for the whole process so its only a rough cap
+	MaxMemory uint64
+	// a callback that fails MaxErrors times within ErrorWindow is disabled until the script is reloaded
+	MaxErrors   int
+	ErrorWindow time.Duration
+	// the deepest js call stack
+	MaxCallStack int
+}
+
+var DefaultLimits = Limits{
+	Timeout:      200 * time.Millisecond,
+	SaveTimeout:  5 * time.Minute,
+	MaxMemory:    256 << 20,
+	MaxErrors:    20,
+	ErrorWindow:  time.Minute,
+	MaxCallStack: 1024,
+}
+
+// CallStats are the counters of one callback
+type CallStats struct {
+	Calls    int
+	Errors   int
+	Timeouts int
+	Time     time.Duration
+	Disabled bool
+
+	recentErrors []time.Time
+}
+
+// Stats returns the counters of every callback that has been called, by script file and callback
+func (v *VM) Stats() map[string]CallStats {
+	v.l.Lock()
+	defer v.l.Unlock()
+	out := make(map[string]CallStats)
+	for _, s := range v.scripts {
+		for name, stats := range s.stats {
+			out[s.filename+": "+name] = *stats
+		}
+	}
+	return out
+}
+
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// watchCall interrupts the runtime when the callback runs too long or allocates too much
+func (s *script) watchCall(timeout time.Duration, done <-chan struct{}, exited chan<- struct{}) {
+	defer close(exited)
+	var timedOut <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timedOut = t.C
+	}
+	var memCheck <-chan time.Time
+	var startHeap uint64
+	if s.v.Limits.MaxMemory > 0 {
+		startHeap = heapBytes()
+		t := time.NewTicker(10 * time.Millisecond)
+		defer t.Stop()
+		memCheck = t.C
+	}
+	for {
+		select {
+		case <-done:
+			return
+		case <-timedOut:
+			s.vm.Interrupt(errTimeout)
+			return
+		case <-memCheck:
+			if heap := heapBytes(); heap > startHeap && heap-startHeap > s.v.Limits.MaxMemory {
+				s.vm.Interrupt(errMemoryLimit)
+				return
+			}
+		}
+	}
+}
+
+// call runs the callback name within the limits, v.l has to be held since the runtime
+// can only be used by one goroutine at a time. disabled callbacks are not called and return no error
+func (s *script) call(name string, timeout time.Duration, fn func() error) error {
+	stats, ok := s.stats[name]
+	if !ok {
+		stats = &CallStats{}
+		s.stats[name] = stats
+	}
+	if stats.Disabled {
+		return nil
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	if timeout > 0 || s.v.Limits.MaxMemory > 0 {
+		go s.watchCall(timeout, done, exited)
+	} else {
+		close(exited)
+	}
+	err := recovery.Call(fn)
+	close(done)
+	<-exited
+	s.vm.ClearInterrupt()
+
+	stats.Calls++
+	stats.Time += time.Since(start)
+	if err == nil {
+		return nil
+	}
+
+	stats.Errors++
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		if errors.Is(err, errTimeout) {
+			stats.Timeouts++
+			err = fmt.Errorf("%s took longer than %s", name, timeout)
+		} else if errors.Is(err, errMemoryLimit) {
+			err = fmt.Errorf("%s used more than %d MB", name, s.v.Limits.MaxMemory>>20)
+		}
+	} else {
+		err = fmt.Errorf("%s: %w", name, err)
+	}
+	err = fmt.Errorf("%s: %w", filepath.Base(s.filename), err)
+
+	if s.v.Limits.MaxErrors > 0 {
+		stats.recentErrors = append(stats.recentErrors, start)
+		for len(stats.recentErrors) > 0 && start.Sub(stats.recentErrors[0]) > s.v.Limits.ErrorWindow {
+			stats.recentErrors = stats.recentErrors[1:]
+		}
+		if len(stats.recentErrors) >= s.v.Limits.MaxErrors {
+			stats.Disabled = true
+			err = fmt.Errorf("%w, failed %d times in %s, disabled until the script is reloaded", err, len(stats.recentErrors), s.v.Limits.ErrorWindow)
+		}
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package scripting
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// readFile reads a file of the script and remembers it for Watch
+func (s *script) readFile(filename string) ([]byte, error) {
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	s.files[filename] = stat.ModTime()
+	return data, nil
+}
+
+// resolveModule finds the file of a relative require path like nodejs does, with .js and /index.js
+func resolveModule(dir, name string) (string, error) {
+	if !strings.HasPrefix(name, "./") && !strings.HasPrefix(name, "../") && !filepath.IsAbs(name) {
+		return "", fmt.Errorf("cannot require %q, only relative paths are supported", name)
+	}
+	base := name
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(dir, name)
+	}
+	for _, filename := range []string{base, base + ".js", filepath.Join(base, "index.js")} {
+		stat, err := os.Stat(filename)
+		if err == nil && !stat.IsDir() {
+			return filename, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find module %q", name)
+}
+
+// requireFrom returns the require function for a module in dir
+func (s *script) requireFrom(dir string) func(name string) goja.Value {
+	return func(name string) goja.Value {
+		filename, err := resolveModule(dir, name)
+		if err != nil {
+			panic(s.vm.NewGoError(err))
+		}
+		exports, err := s.loadModule(filename)
+		if err != nil {
+			var ex *goja.Exception
+			if errors.As(err, &ex) {
+				panic(ex)
+			}
+			panic(s.vm.NewGoError(err))
+		}
+		return exports
+	}
+}
+
+// loadModule runs a commonjs module once and returns its exports.
+// the wrapper keeps the line numbers so source maps still line up
+func (s *script) loadModule(filename string) (goja.Value, error) {
+	if module, ok := s.modules[filename]; ok {
+		return module.Get("exports"), nil
+	}
+	data, err := s.readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	prg, err := goja.Compile(filename, "(function(exports, require, module, __filename, __dirname) {"+string(data)+"\n})", false)
+	if err != nil {
+		return nil, err
+	}
+	wrapper, err := s.vm.RunProgram(prg)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := goja.AssertFunction(wrapper)
+	if !ok {
+		return nil, fmt.Errorf("%s did not compile to a function", filename)
+	}
+
+	exports := s.vm.NewObject()
+	module := s.vm.NewObject()
+	module.Set("exports", exports)
+	// cached before it runs so circular requires get the partial exports
+	s.modules[filename] = module
+	dir := filepath.Dir(filename)
+	_, err = fn(exports, exports, s.vm.ToValue(s.requireFrom(dir)), module, s.vm.ToValue(filename), s.vm.ToValue(dir))
+	if err != nil {
+		delete(s.modules, filename)
+		return nil, err
+	}
+	return module.Get("exports"), nil
+}
@@ -1,35 +1,87 @@
 package scripting
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/df-mc/dragonfly/server/block/cube"
 	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
 	"github.com/dop251/goja"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
 
 	_ "embed"
 )
 
+//go:generate go run ../../../cmd/generate-scripting-types -root ../../..
 //go:embed enums.js
 var enums_js string
 
+// VM runs the loaded scripts, every script has its own runtime and storage.
+// callbacks are called for each script in load order
 type VM struct {
-	vm *goja.Runtime
-	CB struct {
-		OnEntityAdd        func(entity any, metadata *goja.Object) (ignore bool)
-		OnChunkAdd         func(pos world.ChunkPos) (ignore bool)
-		OnEntityDataUpdate func(entity any, metadata *goja.Object)
-	}
+	l       sync.Mutex
+	scripts []*script
+
+	Limits Limits
+
+	// AddCommand and SendMessage are set by the handler for registerCommand
+	AddCommand  func(exec func([]string) bool, cmd protocol.Command)
+	SendMessage func(text string)
+}
+
+type callbacks struct {
+	OnEntityAdd        func(entity any, metadata *goja.Object) (ignore bool)
+	OnChunkAdd         func(pos world.ChunkPos, chunk *goja.Object) (ignore bool)
+	OnEntityDataUpdate func(entity any, metadata *goja.Object)
+	OnBlockNBT         func(pos cube.Pos, nbt map[string]any) (ignore bool)
+	OnSave             func(world *goja.Object)
+}
+
+type script struct {
+	v        *VM
+	filename string
+	vm       *goja.Runtime
+	storage  storage
+	stats    map[string]*CallStats
+	// require cache by absolute path
+	modules map[string]*goja.Object
+	// every file the script loaded with its modification time, for Watch
+	files map[string]time.Time
+	CB    callbacks
 }
 
 func New() *VM {
-	v := &VM{
-		vm: goja.New(),
+	return &VM{
+		Limits: DefaultLimits,
 	}
-	console := v.vm.NewObject()
+}
+
+// init creates a new js runtime with the globals scripts can use
+func (s *script) init() {
+	s.vm = goja.New()
+	s.stats = make(map[string]*CallStats)
+	s.modules = make(map[string]*goja.Object)
+	s.files = make(map[string]time.Time)
+	s.CB = callbacks{}
+	if s.v.Limits.MaxCallStack > 0 {
+		s.vm.SetMaxCallStackSize(s.v.Limits.MaxCallStack)
+	}
+
+	console := s.vm.NewObject()
 	console.Set("log", func(val goja.Value) {
 		if val.SameAs(goja.Undefined()) {
 			logrus.Println("undefined")
@@ -40,7 +92,7 @@ func New() *VM {
 			logrus.Println(val.String())
 			return
 		}
-		obj := val.ToObject(v.vm)
+		obj := val.ToObject(s.vm)
 		data, err := json.MarshalIndent(obj, "", "  ")
 		if err != nil {
 			panic(err)
@@ -48,39 +100,199 @@ func New() *VM {
 		logrus.Println(string(data))
 	})
 
-	v.vm.GlobalObject().Set("console", console)
+	global := s.vm.GlobalObject()
+	global.Set("console", console)
+	global.Set("registerCommand", s.registerCommand)
 
-	_, err := v.vm.RunString(enums_js)
+	store := s.vm.NewObject()
+	store.Set("get", s.storage.Get)
+	store.Set("set", s.storage.Set)
+	store.Set("delete", s.storage.Delete)
+	store.Set("keys", s.storage.Keys)
+	global.Set("storage", store)
+
+	// the main script is a module too, so scripts compiled to commonjs work
+	module := s.vm.NewObject()
+	module.Set("exports", s.vm.NewObject())
+	global.Set("module", module)
+	global.Set("exports", module.Get("exports"))
+	global.Set("require", s.requireFrom(filepath.Dir(s.filename)))
+	global.Set("__filename", s.filename)
+	global.Set("__dirname", filepath.Dir(s.filename))
+
+	_, err := s.vm.RunString(enums_js)
 	if err != nil {
 		panic(err)
 	}
-
-	return v
 }
 
-func (v *VM) tryResolveCB(name string, fun any) {
-	val := v.vm.Get(name)
-	if val == nil {
-		return
+// tryResolveCB looks for a callback in the globals and then in module.exports
+func (s *script) tryResolveCB(name string, fun any) {
+	val := s.vm.Get(name)
+	if val == nil || goja.IsUndefined(val) {
+		exports := s.vm.Get("module").ToObject(s.vm).Get("exports")
+		if exports == nil || goja.IsUndefined(exports) || goja.IsNull(exports) {
+			return
+		}
+		val = exports.ToObject(s.vm).Get(name)
+		if val == nil || goja.IsUndefined(val) {
+			return
+		}
 	}
-	err := v.vm.ExportTo(val, fun)
+	err := s.vm.ExportTo(val, fun)
 	if err != nil {
 		logrus.Error(err)
 	}
 }
 
-func (v *VM) Load(script string) error {
-	_, err := v.vm.RunScript("script.js", script)
+func (s *script) load() error {
+	data, err := s.readFile(s.filename)
+	if err != nil {
+		return err
+	}
+	_, err = s.vm.RunScript(s.filename, string(data))
 	if err != nil {
 		return err
 	}
 
-	v.tryResolveCB("OnEntityAdd", &v.CB.OnEntityAdd)
-	v.tryResolveCB("OnChunkAdd", &v.CB.OnChunkAdd)
-	v.tryResolveCB("OnEntityDataUpdate", &v.CB.OnEntityDataUpdate)
+	s.tryResolveCB("OnEntityAdd", &s.CB.OnEntityAdd)
+	s.tryResolveCB("OnChunkAdd", &s.CB.OnChunkAdd)
+	s.tryResolveCB("OnEntityDataUpdate", &s.CB.OnEntityDataUpdate)
+	s.tryResolveCB("OnBlockNBT", &s.CB.OnBlockNBT)
+	s.tryResolveCB("OnSave", &s.CB.OnSave)
 	return nil
 }
 
+// reload replaces the script with a fresh runtime, the old one is kept if the new script fails to load
+func (s *script) reload() error {
+	oldVM, oldCB, oldStats, oldModules, oldFiles := s.vm, s.CB, s.stats, s.modules, s.files
+	s.init()
+	err := s.load()
+	if err != nil {
+		s.vm, s.CB, s.stats, s.modules, s.files = oldVM, oldCB, oldStats, oldModules, oldFiles
+		return err
+	}
+	return nil
+}
+
+// changed reports if one of the files the script loaded was modified
+func (s *script) changed() bool {
+	for filename, modTime := range s.files {
+		stat, err := os.Stat(filename)
+		if err == nil && !stat.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// scriptFiles returns the scripts in path, all .js files in name order if it is a folder
+func scriptFiles(path string) ([]string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".js" {
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	return files, nil
+}
+
+// Load loads a script file, or every .js file in a folder.
+// each script gets a storage in <name>.storage.json next to it
+func (v *VM) Load(path string) error {
+	files, err := scriptFiles(path)
+	if err != nil {
+		return err
+	}
+
+	v.l.Lock()
+	defer v.l.Unlock()
+	for _, filename := range files {
+		filename, err = filepath.Abs(filename)
+		if err != nil {
+			return err
+		}
+		s := &script{v: v, filename: filename}
+		err = s.storage.open(strings.TrimSuffix(filename, filepath.Ext(filename)) + ".storage.json")
+		if err != nil {
+			return err
+		}
+		s.init()
+		if err = s.load(); err != nil {
+			return err
+		}
+		v.scripts = append(v.scripts, s)
+		logrus.Infof("Scripting: loaded %s", filepath.Base(filename))
+	}
+	return nil
+}
+
+// Hash is a sha256 of all loaded script files, to tell which version of the scripts made a world.
+// empty when no script is loaded
+func (v *VM) Hash() string {
+	v.l.Lock()
+	defer v.l.Unlock()
+	if len(v.scripts) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, s := range v.scripts {
+		filenames := maps.Keys(s.files)
+		sort.Strings(filenames)
+		for _, filename := range filenames {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				continue
+			}
+			h.Write([]byte(filepath.Base(filename)))
+			h.Write(data)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Watch reloads scripts when one of their files changes, until ctx is done
+func (v *VM) Watch(ctx context.Context) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		v.l.Lock()
+		for _, s := range v.scripts {
+			if !s.changed() {
+				continue
+			}
+			if err := s.reload(); err != nil {
+				// dont try again until it is saved again
+				for filename := range s.files {
+					if stat, err := os.Stat(filename); err == nil {
+						s.files[filename] = stat.ModTime()
+					}
+				}
+				logrus.Errorf("Scripting: reload failed, keeping the old script: %s", err)
+				continue
+			}
+			logrus.Infof("Scripting: reloaded %s", filepath.Base(s.filename))
+		}
+		v.l.Unlock()
+	}
+}
+
 type entityDataObject struct {
 	d protocol.EntityMetadata
 	r *goja.Runtime
@@ -141,12 +353,88 @@ func (m entityDataObject) Keys() (keys []string) {
 	return
 }
 
-func (v *VM) OnEntityAdd(entity any, metadata protocol.EntityMetadata) bool {
-	data := v.vm.NewDynamicObject(entityDataObject{metadata, v.vm})
-	return v.CB.OnEntityAdd(entity, data)
+func (v *VM) OnEntityAdd(entity any, metadata protocol.EntityMetadata) (ignore bool, err error) {
+	v.l.Lock()
+	defer v.l.Unlock()
+	for _, s := range v.scripts {
+		if s.CB.OnEntityAdd == nil {
+			continue
+		}
+		err = errors.Join(err, s.call("OnEntityAdd", v.Limits.Timeout, func() error {
+			data := s.vm.NewDynamicObject(entityDataObject{metadata, s.vm})
+			ignore = s.CB.OnEntityAdd(entity, data)
+			return nil
+		}))
+		if ignore {
+			break
+		}
+	}
+	return ignore, err
+}
+
+func (v *VM) OnEntityDataUpdate(entity any, metadata protocol.EntityMetadata) (err error) {
+	v.l.Lock()
+	defer v.l.Unlock()
+	for _, s := range v.scripts {
+		if s.CB.OnEntityDataUpdate == nil {
+			continue
+		}
+		err = errors.Join(err, s.call("OnEntityDataUpdate", v.Limits.Timeout, func() error {
+			data := s.vm.NewDynamicObject(entityDataObject{metadata, s.vm})
+			s.CB.OnEntityDataUpdate(entity, data)
+			return nil
+		}))
+	}
+	return err
+}
+
+func (v *VM) OnChunkAdd(pos world.ChunkPos, ch *chunk.Chunk, nbts map[cube.Pos]map[string]any, biomes *world.BiomeRegistry) (ignore bool, err error) {
+	v.l.Lock()
+	defer v.l.Unlock()
+	for _, s := range v.scripts {
+		if s.CB.OnChunkAdd == nil {
+			continue
+		}
+		err = errors.Join(err, s.call("OnChunkAdd", v.Limits.Timeout, func() error {
+			ignore = s.CB.OnChunkAdd(pos, s.chunkObject(pos, ch, nbts, biomes))
+			return nil
+		}))
+		if ignore {
+			break
+		}
+	}
+	return ignore, err
+}
+
+func (v *VM) OnBlockNBT(pos cube.Pos, nbt map[string]any) (ignore bool, err error) {
+	v.l.Lock()
+	defer v.l.Unlock()
+	for _, s := range v.scripts {
+		if s.CB.OnBlockNBT == nil {
+			continue
+		}
+		err = errors.Join(err, s.call("OnBlockNBT", v.Limits.Timeout, func() error {
+			ignore = s.CB.OnBlockNBT(pos, nbt)
+			return nil
+		}))
+		if ignore {
+			break
+		}
+	}
+	return ignore, err
 }
 
-func (v *VM) OnEntityDataUpdate(entity any, metadata protocol.EntityMetadata) {
-	data := v.vm.NewDynamicObject(entityDataObject{metadata, v.vm})
-	v.CB.OnEntityDataUpdate(entity, data)
+func (v *VM) OnSave(name string, dim world.Dimension, w SaveWorld, biomes *world.BiomeRegistry) (err error) {
+	v.l.Lock()
+	defer v.l.Unlock()
+	for _, s := range v.scripts {
+		if s.CB.OnSave == nil {
+			continue
+		}
+		err = errors.Join(err, s.call("OnSave", v.Limits.SaveTimeout, func() error {
+			s.CB.OnSave(s.worldObject(name, dim, w, biomes))
+			return nil
+		}))
+	}
+	return err
 }
@@ -0,0 +1,82 @@
+package scripting
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// a reload registers the commands again from the Watch goroutine while commands run, run with -race
+func TestReloadCommand(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "version.js")
+	write := func(version string, modTime time.Time) {
+		err := os.WriteFile(filename, []byte(`registerCommand("version", [], () => "`+version+`")`), 0o777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.Chtimes(filename, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("v1", time.Now().Add(-time.Hour))
+
+	var l sync.Mutex
+	commands := make(map[string]func([]string) bool)
+	var message string
+	v := New()
+	v.AddCommand = func(exec func([]string) bool, cmd protocol.Command) {
+		l.Lock()
+		commands[cmd.Name] = exec
+		l.Unlock()
+	}
+	v.SendMessage = func(text string) {
+		l.Lock()
+		message = text
+		l.Unlock()
+	}
+	if err := v.Load(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func() string {
+		l.Lock()
+		exec := commands["version"]
+		l.Unlock()
+		exec(nil)
+		l.Lock()
+		defer l.Unlock()
+		return message
+	}
+	if msg := run(); msg != "v1" {
+		t.Fatalf("got %q, expected v1", msg)
+	}
+	l.Lock()
+	first := commands["version"]
+	l.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go v.Watch(ctx)
+	write("v2", time.Now())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for run() != "v2" {
+		if time.Now().After(deadline) {
+			t.Fatal("the script was not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the command of the old runtime is still in the proxy, it must not run the old script
+	first(nil)
+	l.Lock()
+	defer l.Unlock()
+	if message != "/version was removed when the script reloaded" {
+		t.Errorf("old command sent %q", message)
+	}
+}
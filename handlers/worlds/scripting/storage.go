@@ -0,0 +1,73 @@
+package scripting
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"golang.org/x/exp/maps"
+)
+
+// storage is the key value store of a script, it is written to a json file on every change
+// so it is kept across sessions
+type storage struct {
+	l        sync.Mutex
+	filename string
+	data     map[string]any
+}
+
+func (s *storage) open(filename string) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.filename = filename
+	s.data = make(map[string]any)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(data, &s.data)
+}
+
+func (s *storage) save() error {
+	if s.filename == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.data, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.filename, data, 0o644)
+}
+
+func (s *storage) Get(key string) any {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.data[key]
+}
+
+func (s *storage) Set(key string, value any) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	if s.data == nil {
+		s.data = make(map[string]any)
+	}
+	s.data[key] = value
+	return s.save()
+}
+
+func (s *storage) Delete(key string) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	delete(s.data, key)
+	return s.save()
+}
+
+func (s *storage) Keys() []string {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return maps.Keys(s.data)
+}
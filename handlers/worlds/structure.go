@@ -0,0 +1,77 @@
+package worlds
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils/structure"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/flytam/filenamify"
+	"github.com/sirupsen/logrus"
+)
+
+// parseCoord parses one coordinate, ~ is relative to the player
+func parseCoord(s string, player float32) (int, error) {
+	base := 0
+	if strings.HasPrefix(s, "~") {
+		base = int(math.Floor(float64(player)))
+		s = s[1:]
+		if s == "" {
+			return base, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	return base + v, err
+}
+
+// exportStructure handles export-structure x1 y1 z1 x2 y2 z2 name
+func (w *worldsHandler) exportStructure(args []string) bool {
+	if len(args) != 7 {
+		w.proxy.SendMessage("usage: export-structure x1 y1 z1 x2 y2 z2 name")
+		return true
+	}
+
+	playerPos := w.proxy.Player.Position
+	var corners [2]cube.Pos
+	for i := 0; i < 6; i++ {
+		v, err := parseCoord(args[i], playerPos[i%3])
+		if err != nil {
+			w.proxy.SendMessage(fmt.Sprintf("invalid coordinate %s", args[i]))
+			return true
+		}
+		corners[i/3][i%3] = v
+	}
+	name := args[6]
+	if name == "" || name == "." || name == ".." {
+		w.proxy.SendMessage(fmt.Sprintf("invalid structure name %q", name))
+		return true
+	}
+	// the name comes from chat, it must not leave the structures folder
+	name, _ = filenamify.FilenamifyV2(name)
+
+	w.worldStateLock.Lock()
+	s, err := structure.Export(w.currentWorld, corners[0], corners[1])
+	w.worldStateLock.Unlock()
+	if err != nil {
+		logrus.Error(err)
+		w.proxy.SendMessage(fmt.Sprintf("export failed: %s", err))
+		return true
+	}
+
+	folder := fmt.Sprintf("worlds/%s/structures", w.serverState.Name)
+	os.MkdirAll(folder, 0o777)
+	filename := path.Join(folder, name+".mcstructure")
+	err = s.WriteFile(filename)
+	if err != nil {
+		logrus.Error(err)
+		w.proxy.SendMessage(fmt.Sprintf("export failed: %s", err))
+		return true
+	}
+	logrus.Infof("Exported structure %s", filename)
+	w.proxy.SendMessage(fmt.Sprintf("Exported %dx%dx%d structure to %s", s.Size[0], s.Size[1], s.Size[2], filename))
+	return true
+}
@@ -0,0 +1,204 @@
+package worlds
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"image/png"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+//go:embed webmap.html
+var webMapHTML []byte
+
+// webMapEvent is sent to the browser as json over the websocket
+type webMapEvent struct {
+	Type   string              `json:"type"`
+	Chunks []protocol.ChunkPos `json:"chunks,omitempty"`
+	X      float32             `json:"x"`
+	Z      float32             `json:"z"`
+	Yaw    float32             `json:"yaw"`
+}
+
+// webMap serves the chunks of the MapUI to a browser and pushes updates over a websocket
+type webMap struct {
+	m      *MapUI
+	server *http.Server
+
+	l       sync.Mutex
+	clients map[chan webMapEvent]struct{}
+	player  webMapEvent
+}
+
+func newWebMap(m *MapUI, addr string) *webMap {
+	wm := &webMap{
+		m:       m,
+		clients: make(map[chan webMapEvent]struct{}),
+		player:  webMapEvent{Type: "player"},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", wm.serveIndex)
+	mux.HandleFunc("/chunk", wm.serveChunk)
+	mux.Handle("/ws", websocket.Server{Handler: wm.serveWS, Handshake: wm.checkOrigin})
+	wm.server = &http.Server{Addr: addr, Handler: mux}
+	return wm
+}
+
+// Start listens on the address until ctx is done
+func (wm *webMap) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", wm.server.Addr)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Web map on http://%s", ln.Addr())
+	go func() {
+		err := wm.server.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Error(err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		wm.server.Close()
+	}()
+	return nil
+}
+
+func (wm *webMap) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(webMapHTML)
+}
+
+// serveChunk writes the prerendered chunk at ?x=&z= as png
+func (wm *webMap) serveChunk(w http.ResponseWriter, r *http.Request) {
+	x, errX := strconv.Atoi(r.URL.Query().Get("x"))
+	z, errZ := strconv.Atoi(r.URL.Query().Get("z"))
+	if errX != nil || errZ != nil {
+		http.Error(w, "invalid chunk", http.StatusBadRequest)
+		return
+	}
+	wm.m.l.Lock()
+	img, ok := wm.m.renderedChunks[protocol.ChunkPos{int32(x), int32(z)}]
+	wm.m.l.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-cache")
+	png.Encode(w, img)
+}
+
+// checkOrigin only lets the page served by this map connect, on the listen host or an ip,
+// so other websites cant read the map by pointing their domain at it
+func (wm *webMap) checkOrigin(config *websocket.Config, r *http.Request) error {
+	if config.Origin == nil || config.Origin.Host != r.Host {
+		return errors.New("web map: origin not allowed")
+	}
+	host := config.Origin.Hostname()
+	listenHost, _, _ := net.SplitHostPort(wm.server.Addr)
+	switch {
+	case listenHost != "" && host == listenHost:
+	case host == "localhost", net.ParseIP(host) != nil:
+	default:
+		return errors.New("web map: origin not allowed")
+	}
+	return nil
+}
+
+func (wm *webMap) serveWS(ws *websocket.Conn) {
+	events := make(chan webMapEvent, 64)
+
+	// everything rendered so far, then updates
+	wm.m.l.Lock()
+	chunks := make([]protocol.ChunkPos, 0, len(wm.m.renderedChunks))
+	for pos := range wm.m.renderedChunks {
+		chunks = append(chunks, pos)
+	}
+	wm.l.Lock()
+	wm.clients[events] = struct{}{}
+	player := wm.player
+	wm.l.Unlock()
+	wm.m.l.Unlock()
+
+	defer func() {
+		wm.l.Lock()
+		delete(wm.clients, events)
+		wm.l.Unlock()
+	}()
+
+	if err := websocket.JSON.Send(ws, webMapEvent{Type: "chunks", Chunks: chunks}); err != nil {
+		return
+	}
+	if err := websocket.JSON.Send(ws, player); err != nil {
+		return
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		var msg string
+		for websocket.Message.Receive(ws, &msg) == nil {
+		}
+		close(closed)
+	}()
+
+	for {
+		select {
+		case e := <-events:
+			if err := websocket.JSON.Send(ws, e); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// broadcast sends e to all connected browsers, slow ones miss events instead of blocking the map
+func (wm *webMap) broadcast(e webMapEvent) {
+	wm.l.Lock()
+	defer wm.l.Unlock()
+	for c := range wm.clients {
+		select {
+		case c <- e:
+		default:
+		}
+	}
+}
+
+// UpdateChunks tells the browsers to reload these chunks
+func (wm *webMap) UpdateChunks(chunks []protocol.ChunkPos) {
+	if len(chunks) == 0 {
+		return
+	}
+	wm.broadcast(webMapEvent{Type: "chunks", Chunks: chunks})
+}
+
+// UpdatePlayer sends the player position and heading if it changed
+func (wm *webMap) UpdatePlayer(pos mgl32.Vec3, yaw float32) {
+	wm.l.Lock()
+	changed := wm.player.X != pos.X() || wm.player.Z != pos.Z() || wm.player.Yaw != yaw
+	wm.player.X, wm.player.Z, wm.player.Yaw = pos.X(), pos.Z(), yaw
+	player := wm.player
+	wm.l.Unlock()
+	if changed {
+		wm.broadcast(player)
+	}
+}
+
+// Reset clears the map in the browsers
+func (wm *webMap) Reset() {
+	wm.broadcast(webMapEvent{Type: "reset"})
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"image/png"
+	"maps"
 	"math"
 	"math/rand"
 	"net"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
@@ -20,7 +22,12 @@ import (
 	"github.com/bedrock-tool/bedrocktool/utils"
 	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
 	"github.com/bedrock-tool/bedrocktool/utils/proxy"
+	"github.com/bedrock-tool/bedrocktool/utils/report"
 	"github.com/bedrock-tool/bedrocktool/utils/resourcepack"
+	"github.com/bedrock-tool/bedrocktool/utils/serverdata"
+	"github.com/bedrock-tool/bedrocktool/utils/textindex"
+	"github.com/bedrock-tool/bedrocktool/utils/trajectory"
+	"github.com/bedrock-tool/bedrocktool/utils/worldmeta"
 	"github.com/google/uuid"
 
 	"github.com/df-mc/dragonfly/server/block/cube"
@@ -35,17 +42,32 @@ import (
 )
 
 type WorldSettings struct {
-	VoidGen         bool
-	WithPacks       bool
-	SaveImage       bool
-	SaveEntities    bool
-	SaveInventories bool
-	ExcludedMobs    []string
-	StartPaused     bool
-	PreloadReplay   string
-	ChunkRadius     int32
-	Script          string
-	Players         bool
+	VoidGen          bool
+	WithPacks        bool
+	SaveImage        bool
+	SaveEntities     bool
+	SaveInventories  bool
+	ExcludedMobs     []string
+	StartPaused      bool
+	PreloadReplay    string
+	ChunkRadius      int32
+	Scripts          []string
+	ScriptLimits     scripting.Limits
+	Players          bool
+	PlayersNoAI      bool
+	PlayersInvuln    bool
+	Dedupe           string
+	PreserveSettings bool
+	WebMap           string
+	ChunkHistory     bool
+	// csv or geojson, empty to not record trajectories
+	Trajectories    string
+	TrajectoryTypes []string
+	DespawnPolicy   worldstate.DespawnPolicy
+	// save the creative inventory and commands next to the world
+	ServerData bool
+	// for bedrocktool.json, empty when not connecting to a realm
+	RealmID string
 }
 
 type serverState struct {
@@ -54,16 +76,23 @@ type serverState struct {
 	haveStartGame bool
 	worldCounter  int
 	WorldName     string
+	address       string
 	radius        int32
 
 	biomes *world.BiomeRegistry
 	blocks *world.BlockRegistryImpl
+	// ids the custom biomes have in the saved chunks, by name
+	customBiomes map[string]int
 
 	openItemContainers map[byte]*itemContainer
 	playerInventory    []protocol.ItemInstance
 	packs              []utils.Pack
 	dimensions         map[int]protocol.DimensionDefinition
 	playerSkins        map[uuid.UUID]*protocol.Skin
+	startGame          *packet.StartGame
+	gameRules          []protocol.GameRule
+	creativeItems      []protocol.CreativeItem
+	commands           *packet.AvailableCommands
 
 	Name string
 }
@@ -83,6 +112,12 @@ type worldsHandler struct {
 	currentWorld   *worldstate.World
 	worldStateLock sync.Mutex
 
+	// lock for the fingerprints manifest, worlds are saved in parallel
+	fingerprintLock sync.Mutex
+	// worlds that are still being saved, closed when their .mcworld is done
+	saving     map[string]chan struct{}
+	savingLock sync.Mutex
+
 	serverState  serverState
 	settings     WorldSettings
 	customBlocks []protocol.BlockEntry
@@ -113,16 +148,23 @@ func NewWorldsHandler(settings WorldSettings) *proxy.Handler {
 			dimensions:         make(map[int]protocol.DimensionDefinition),
 			playerSkins:        make(map[uuid.UUID]*protocol.Skin),
 			biomes:             world.DefaultBiomes.Clone(),
+			customBiomes:       make(map[string]int),
 		},
 		settings: settings,
+		saving:   make(map[string]chan struct{}),
 	}
 	w.mapUI = NewMapUI(w)
 	w.scripting = scripting.New()
+	if settings.ScriptLimits != (scripting.Limits{}) {
+		w.scripting.Limits = settings.ScriptLimits
+	}
 
 	h := &proxy.Handler{
 		Name: "Worlds",
 		ProxyRef: func(pc *proxy.Context) {
 			w.proxy = pc
+			w.scripting.AddCommand = pc.AddCommand
+			w.scripting.SendMessage = pc.SendMessage
 
 			/*
 				w.proxy.PlayerMoveCB = append(w.proxy.PlayerMoveCB, func() {
@@ -186,12 +228,18 @@ func NewWorldsHandler(settings WorldSettings) *proxy.Handler {
 				Name:        "save-world",
 				Description: "immediately save and reset the world state",
 			})
+
+			w.proxy.AddCommand(w.exportStructure, protocol.Command{
+				Name:        "export-structure",
+				Description: "export x1 y1 z1 x2 y2 z2 as name.mcstructure",
+			})
 		},
 
 		AddressAndName: func(address, hostname string) (err error) {
 			w.bp = behaviourpack.New(hostname)
 			w.rp = resourcepack.New()
 			w.serverState.Name = hostname
+			w.serverState.address = address
 
 			// initialize a worldstate
 			w.currentWorld, err = worldstate.New(w.chunkCB, w.serverState.dimensions, nil, nil)
@@ -199,16 +247,21 @@ func NewWorldsHandler(settings WorldSettings) *proxy.Handler {
 				return err
 			}
 			w.currentWorld.VoidGen = w.settings.VoidGen
+			w.currentWorld.KeepHistory = w.settings.ChunkHistory
+			w.currentWorld.Trajectories = w.newTrajectoryRecorder()
+			w.currentWorld.DespawnPolicy = w.settings.DespawnPolicy
 			if settings.StartPaused {
 				w.currentWorld.PauseCapture()
 			}
 
-			if settings.Script != "" {
-				err := w.scripting.Load(settings.Script)
-				if err != nil {
+			for _, path := range settings.Scripts {
+				if err := w.scripting.Load(path); err != nil {
 					return err
 				}
 			}
+			if len(settings.Scripts) > 0 {
+				go w.scripting.Watch(w.ctx)
+			}
 
 			err = w.preloadReplay()
 			if err != nil {
@@ -414,6 +467,8 @@ func (w *worldsHandler) saveWorldState(worldState *worldstate.World) error {
 	w.proxy.SendMessage(text)
 
 	filename := worldState.Folder + ".mcworld"
+	done := w.startSaving(worldState.Name)
+	defer done()
 
 	messages.Router.Handle(&messages.Message{
 		Source: "subcommand",
@@ -428,12 +483,63 @@ func (w *worldsHandler) saveWorldState(worldState *worldstate.World) error {
 		},
 	})
 
-	err := worldState.Finish(w.playerData(), w.settings.ExcludedMobs, w.settings.Players, spawnPos, w.proxy.Server.GameData(), w.bp)
+	players := worldstate.PlayerSettings{
+		Save:         w.settings.Players,
+		NoAI:         w.settings.PlayersNoAI,
+		Invulnerable: w.settings.PlayersInvuln,
+	}
+	level := worldstate.LevelSettings{
+		GameData:  w.proxy.Server.GameData(),
+		StartGame: w.serverState.startGame,
+		GameRules: w.serverState.gameRules,
+		Preserve:  w.settings.PreserveSettings,
+	}
+	w.scriptSave(worldState)
+	err := worldState.Finish(w.playerData(), w.settings.ExcludedMobs, players, spawnPos, level, w.bp)
 	if err != nil {
 		return err
 	}
 	w.AddPacks(worldState.Folder)
 
+	err = worldState.TextIndex.WriteFile(filepath.Join(worldState.Folder, textindex.Filename))
+	if err != nil {
+		logrus.Errorf("text index: %s", err)
+	}
+
+	var br world.BlockRegistry
+	if w.serverState.blocks != nil {
+		br = w.serverState.blocks
+	}
+	worldReport, err := report.GenerateFile(worldState.Folder, worldState.Name, worldState.Folder+".report.json", br)
+	if err != nil {
+		logrus.Errorf("world report: %s", err)
+	}
+
+	meta := w.worldMetadata(worldState)
+	if worldReport != nil {
+		meta.AddReport(worldReport)
+	}
+	err = meta.WriteFile(worldState.Folder)
+	if err != nil {
+		logrus.Errorf("world metadata: %s", err)
+	}
+
+	if worldState.Trajectories != nil {
+		err = worldState.Trajectories.WriteFile(worldState.Folder+".trajectories."+w.settings.Trajectories, w.settings.Trajectories)
+		if err != nil {
+			logrus.Errorf("trajectories: %s", err)
+		}
+	}
+
+	if w.settings.ServerData {
+		w.saveServerData(worldState.Folder + ".server")
+	}
+
+	// after the sidecars so duplicates keep their own
+	if w.dedupeWorld(worldState, filename) {
+		return nil
+	}
+
 	// zip it
 	err = utils.ZipFolder(filename, worldState.Folder)
 	if err != nil {
@@ -443,6 +549,74 @@ func (w *worldsHandler) saveWorldState(worldState *worldstate.World) error {
 	return nil
 }
 
+// worldMetadata is the bedrocktool.json of a world without the counts from the report
+func (w *worldsHandler) worldMetadata(worldState *worldstate.World) *worldmeta.Metadata {
+	meta := &worldmeta.Metadata{
+		Name: worldState.Name,
+		Server: worldmeta.Server{
+			Address: w.serverState.address,
+			Name:    w.serverState.Name,
+			RealmID: w.settings.RealmID,
+		},
+		CaptureStart:    worldState.Started,
+		CaptureEnd:      time.Now(),
+		Protocol:        protocol.CurrentProtocol,
+		ProtocolVersion: protocol.CurrentVersion,
+		Packs:           []worldmeta.Pack{},
+		Settings: worldmeta.Settings{
+			Void:       w.settings.VoidGen,
+			Scripts:    w.settings.Scripts,
+			ScriptHash: w.scripting.Hash(),
+		},
+	}
+	if w.serverState.startGame != nil {
+		meta.GameVersion = w.serverState.startGame.GameVersion
+	}
+	if len(w.serverState.customBiomes) > 0 {
+		meta.CustomBiomes = maps.Clone(w.serverState.customBiomes)
+	}
+	for _, mob := range w.settings.ExcludedMobs {
+		if mob != "" {
+			meta.Settings.ExcludedMobs = append(meta.Settings.ExcludedMobs, mob)
+		}
+	}
+	for _, pack := range w.serverState.packs {
+		base := pack.Base()
+		meta.Packs = append(meta.Packs, worldmeta.Pack{
+			Name:      base.Name(),
+			UUID:      base.UUID(),
+			Version:   base.Manifest().Header.Version,
+			Encrypted: base.Encrypted(),
+		})
+	}
+	return meta
+}
+
+// saveServerData writes the creative inventory and command docs to dir
+func (w *worldsHandler) saveServerData(dir string) {
+	var creative []serverdata.CreativeItem
+	if len(w.serverState.creativeItems) > 0 {
+		icons := serverdata.NewIcons(w.serverState.packs)
+		creative = serverdata.Creative(w.serverState.creativeItems, w.bp.ItemName, w.bp.ItemIcon, icons)
+	}
+	var commands []serverdata.Command
+	if w.serverState.commands != nil {
+		commands = serverdata.Commands(w.serverState.commands)
+	}
+	err := serverdata.Write(dir, creative, commands)
+	if err != nil {
+		logrus.Errorf("server data: %s", err)
+	}
+}
+
+// newTrajectoryRecorder returns nil when trajectories are not recorded
+func (w *worldsHandler) newTrajectoryRecorder() *trajectory.Recorder {
+	if w.settings.Trajectories == "" {
+		return nil
+	}
+	return trajectory.NewRecorder(w.settings.TrajectoryTypes)
+}
+
 func (w *worldsHandler) chunkCB(cp world.ChunkPos, c *chunk.Chunk) {
 	w.mapUI.SetChunk(cp, c, false)
 }
@@ -454,6 +628,9 @@ func (w *worldsHandler) reset(dim world.Dimension) (err error) {
 		return err
 	}
 	w.currentWorld.VoidGen = w.settings.VoidGen
+	w.currentWorld.KeepHistory = w.settings.ChunkHistory
+	w.currentWorld.Trajectories = w.newTrajectoryRecorder()
+	w.currentWorld.DespawnPolicy = w.settings.DespawnPolicy
 	w.currentWorld.SetDimension(dim)
 
 	w.openWorldState(false)
@@ -3,6 +3,7 @@ package worldstate
 import (
 	"image"
 	"image/draw"
+	"maps"
 
 	"github.com/bedrock-tool/bedrocktool/utils"
 	"github.com/df-mc/dragonfly/server/block/cube"
@@ -20,6 +21,19 @@ type worldStateDefer struct {
 
 func (w *worldStateDefer) StoreChunk(pos world.ChunkPos, ch *chunk.Chunk, blockNBT map[cube.Pos]DummyBlock) {
 	w.chunks[pos] = ch
+	// the resent chunk decides which block entities exist, a broken chest or sign is not in it anymore.
+	// keep what an opened container added to the ones that are still there
+	for p, b := range w.blockNBTs[pos] {
+		nb, ok := blockNBT[p]
+		if !ok || nb.ID != b.ID || nb.NBT == nil {
+			continue
+		}
+		for k, v := range b.NBT {
+			if _, ok := nb.NBT[k]; !ok {
+				nb.NBT[k] = v
+			}
+		}
+	}
 	w.blockNBTs[pos] = blockNBT
 }
 
@@ -27,30 +41,37 @@ func (w *worldStateDefer) StoreMap(m *packet.ClientBoundMapItemData) {
 	m1, ok := w.maps[m.MapID]
 	if !ok {
 		m1 = &Map{
-			MapID:     m.MapID,
-			Height:    128,
-			Width:     128,
-			Scale:     1,
-			Dimension: 0,
-			ZCenter:   m.Origin.Z(),
-			XCenter:   m.Origin.X(),
+			MapID:       m.MapID,
+			Height:      128,
+			Width:       128,
+			ParentMapId: -1,
 		}
 		w.maps[m.MapID] = m1
 	}
-	draw.Draw(&image.RGBA{
-		Pix:    m1.Colors[:],
-		Rect:   image.Rect(0, 0, int(m.Width), int(m.Height)),
-		Stride: int(m.Width) * 4,
-	}, image.Rect(
-		int(m.XOffset), int(m.YOffset),
-		int(m.Width), int(m.Height),
-	), utils.RGBA2Img(
-		m.Pixels,
-		image.Rect(
-			0, 0,
-			int(m.Width), int(m.Height),
-		),
-	), image.Point{}, draw.Over)
+	m1.Dimension = m.Dimension
+	m1.MapLocked = m.LockedMap
+	if m.UpdateFlags != 0 {
+		m1.Scale = m.Scale
+	}
+
+	if m.UpdateFlags&packet.MapUpdateFlagDecoration != 0 {
+		m1.Decorations = mapDecorations(m.Decorations, m.TrackedObjects)
+	}
+
+	if m.UpdateFlags&packet.MapUpdateFlagTexture != 0 {
+		m1.XCenter = m.Origin.X()
+		m1.ZCenter = m.Origin.Z()
+		draw.Draw(m1.image(), image.Rect(
+			int(m.XOffset), int(m.YOffset),
+			int(m.XOffset+m.Width), int(m.YOffset+m.Height),
+		), utils.RGBA2Img(
+			m.Pixels,
+			image.Rect(
+				0, 0,
+				int(m.Width), int(m.Height),
+			),
+		), image.Point{}, draw.Src)
+	}
 }
 
 func (w *worldStateDefer) cullChunks() {
@@ -81,13 +102,17 @@ func (w *worldStateDefer) ApplyTo(w2 worldStateInterface, around cube.Pos, radiu
 		}
 	}
 
-	for k, es := range w.entities {
+	if w2, ok := w2.(*World); ok {
+		maps.Copy(w2.memState.maps, w.maps)
+	}
+
+	for _, es := range w.entities {
 		x := int(es.Position[0])
 		z := int(es.Position[2])
 		dist := i32.Sqrt(i32.Pow(int32(x-around.X()), 2) + i32.Pow(int32(z-around.Z()), 2))
-		e2 := w2.GetEntity(k)
+		e2 := w2.GetEntity(es.RuntimeID)
 		if e2 != nil || dist < radius*16 || radius < 0 {
-			w2.StoreEntity(k, es)
+			w2.StoreEntity(es)
 		}
 	}
 }
@@ -0,0 +1,122 @@
+package worldstate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// what happens to an entity when the server removes it
+const (
+	// save it where it was last seen
+	DespawnKeepLastSeen = "keep-last-seen"
+	// dont save it
+	DespawnDropOnRemove = "drop-on-remove"
+	// only save it when it has a name tag, is tamed or leashed
+	DespawnKeepIfPersistent = "keep-if-persistent"
+)
+
+var despawnPolicies = []string{DespawnKeepLastSeen, DespawnDropOnRemove, DespawnKeepIfPersistent}
+
+// DespawnPolicy picks what to do with removed entities by entity type,
+// the zero value keeps everything where it was last seen
+type DespawnPolicy struct {
+	Default string
+	Types   map[string]string
+}
+
+// ParseDespawnPolicy parses "policy,type=policy,...", types without a namespace are in minecraft:
+func ParseDespawnPolicy(s string) (DespawnPolicy, error) {
+	var p DespawnPolicy
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		entityType, policy, ok := strings.Cut(part, "=")
+		if !ok {
+			policy = entityType
+		}
+		policy = strings.TrimSpace(policy)
+		if !isDespawnPolicy(policy) {
+			return p, fmt.Errorf("unknown despawn policy %q, valid are %s", policy, strings.Join(despawnPolicies, ", "))
+		}
+		if !ok {
+			p.Default = policy
+			continue
+		}
+		entityType = strings.TrimSpace(entityType)
+		if !strings.Contains(entityType, ":") {
+			entityType = "minecraft:" + entityType
+		}
+		if p.Types == nil {
+			p.Types = make(map[string]string)
+		}
+		p.Types[entityType] = policy
+	}
+	return p, nil
+}
+
+func isDespawnPolicy(s string) bool {
+	for _, policy := range despawnPolicies {
+		if s == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// For returns the policy of an entity type
+func (p DespawnPolicy) For(entityType string) string {
+	if policy, ok := p.Types[entityType]; ok {
+		return policy
+	}
+	if p.Default != "" {
+		return p.Default
+	}
+	return DespawnKeepLastSeen
+}
+
+// keep reports if a removed entity is still saved
+func (p DespawnPolicy) keep(es *EntityState) bool {
+	switch p.For(es.EntityType) {
+	case DespawnDropOnRemove:
+		return false
+	case DespawnKeepIfPersistent:
+		return es.persistent()
+	default:
+		return true
+	}
+}
+
+// persistent is true for entities that the game would not despawn either
+func (s *EntityState) persistent() bool {
+	if name, _ := s.Metadata[protocol.EntityDataKeyName].(string); name != "" {
+		return true
+	}
+	flags, ok := s.Metadata[protocol.EntityDataKeyFlags].(int64)
+	if !ok {
+		return false
+	}
+	return flags&(1<<protocol.EntityDataFlagTamed) != 0 || flags&(1<<protocol.EntityDataFlagLeashed) != 0
+}
+
+// RemoveActor handles the server removing an entity, what is kept depends on DespawnPolicy
+func (w *World) RemoveActor(id EntityUniqueID) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	states := []*worldStateDefer{w.memState}
+	if w.paused {
+		states = append(states, w.pausedState)
+	}
+	for _, state := range states {
+		es := state.GetEntityByUniqueID(id)
+		if es == nil {
+			continue
+		}
+		if !w.DespawnPolicy.keep(es) {
+			state.DeleteEntity(id)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package worldstate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+func TestParseDespawnPolicy(t *testing.T) {
+	type test struct {
+		s        string
+		expected DespawnPolicy
+		err      bool
+	}
+
+	var tests = []test{
+		{s: "", expected: DespawnPolicy{}},
+		{s: "drop-on-remove", expected: DespawnPolicy{Default: DespawnDropOnRemove}},
+		{
+			s: "keep-last-seen,zombie=drop-on-remove,example:boss=keep-if-persistent",
+			expected: DespawnPolicy{
+				Default: DespawnKeepLastSeen,
+				Types: map[string]string{
+					"minecraft:zombie": DespawnDropOnRemove,
+					"example:boss":     DespawnKeepIfPersistent,
+				},
+			},
+		},
+		{
+			s: " cow = drop-on-remove , keep-if-persistent ,",
+			expected: DespawnPolicy{
+				Default: DespawnKeepIfPersistent,
+				Types:   map[string]string{"minecraft:cow": DespawnDropOnRemove},
+			},
+		},
+		{s: "forget", err: true},
+		{s: "zombie=forget", err: true},
+		{s: "keep-last-seen,zombie", err: true},
+	}
+
+	for _, tc := range tests {
+		p, err := ParseDespawnPolicy(tc.s)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %+v", tc.s, p)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", tc.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(p, tc.expected) {
+			t.Errorf("%q: got %+v, expected %+v", tc.s, p, tc.expected)
+		}
+	}
+}
+
+func TestDespawnPolicyFor(t *testing.T) {
+	p, err := ParseDespawnPolicy("zombie=drop-on-remove")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy := p.For("minecraft:zombie"); policy != DespawnDropOnRemove {
+		t.Errorf("zombie: got %s", policy)
+	}
+	if policy := p.For("minecraft:cow"); policy != DespawnKeepLastSeen {
+		t.Errorf("cow: got %s, expected the default %s", policy, DespawnKeepLastSeen)
+	}
+}
+
+func TestPersistent(t *testing.T) {
+	type test struct {
+		name     string
+		metadata func(m protocol.EntityMetadata)
+		expected bool
+	}
+
+	var tests = []test{
+		{name: "nothing", metadata: func(m protocol.EntityMetadata) {}, expected: false},
+		{
+			name:     "name tag",
+			metadata: func(m protocol.EntityMetadata) { m[protocol.EntityDataKeyName] = "Bob" },
+			expected: true,
+		},
+		{
+			name:     "empty name",
+			metadata: func(m protocol.EntityMetadata) { m[protocol.EntityDataKeyName] = "" },
+			expected: false,
+		},
+		{
+			name:     "tamed",
+			metadata: func(m protocol.EntityMetadata) { m.SetFlag(protocol.EntityDataKeyFlags, protocol.EntityDataFlagTamed) },
+			expected: true,
+		},
+		{
+			name: "leashed",
+			metadata: func(m protocol.EntityMetadata) {
+				m.SetFlag(protocol.EntityDataKeyFlags, protocol.EntityDataFlagLeashed)
+			},
+			expected: true,
+		},
+		{
+			name:     "other flags",
+			metadata: func(m protocol.EntityMetadata) { m.SetFlag(protocol.EntityDataKeyFlags, protocol.EntityDataFlagOnFire) },
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		m := protocol.NewEntityMetadata()
+		tc.metadata(m)
+		es := &EntityState{EntityType: "minecraft:wolf", Metadata: m}
+		if got := es.persistent(); got != tc.expected {
+			t.Errorf("%s: got %v, expected %v", tc.name, got, tc.expected)
+		}
+
+		p := DespawnPolicy{Default: DespawnKeepIfPersistent}
+		if got := p.keep(es); got != tc.expected {
+			t.Errorf("%s: keep-if-persistent kept it: %v, expected %v", tc.name, got, tc.expected)
+		}
+	}
+}
@@ -11,19 +11,61 @@ type EntityRuntimeID = uint64
 type EntityUniqueID = int64
 
 type worldEntities struct {
-	entities    map[EntityRuntimeID]*EntityState
+	// by unique id, so an entity that is added again under a new runtime id is only saved once
+	entities    map[EntityUniqueID]*EntityState
+	runtimeIDs  map[EntityRuntimeID]EntityUniqueID
 	entityLinks map[EntityUniqueID]map[EntityUniqueID]struct{}
 	blockNBTs   map[world.ChunkPos]map[cube.Pos]DummyBlock
 }
 
-func (w *worldEntities) StoreEntity(id EntityRuntimeID, es *EntityState) {
-	w.entities[id] = es
+func newWorldEntities() worldEntities {
+	return worldEntities{
+		entities:    make(map[EntityUniqueID]*EntityState),
+		runtimeIDs:  make(map[EntityRuntimeID]EntityUniqueID),
+		entityLinks: make(map[EntityUniqueID]map[EntityUniqueID]struct{}),
+		blockNBTs:   make(map[world.ChunkPos]map[cube.Pos]DummyBlock),
+	}
+}
+
+func (w *worldEntities) StoreEntity(es *EntityState) {
+	w.entities[es.UniqueID] = es
+	w.runtimeIDs[es.RuntimeID] = es.UniqueID
 }
 
 func (w *worldEntities) GetEntity(id EntityRuntimeID) *EntityState {
+	uniqueID, ok := w.runtimeIDs[id]
+	if !ok {
+		return nil
+	}
+	// the runtime id is stale when the entity was added again with a new one
+	es := w.entities[uniqueID]
+	if es == nil || es.RuntimeID != id {
+		return nil
+	}
+	return es
+}
+
+func (w *worldEntities) GetEntityByUniqueID(id EntityUniqueID) *EntityState {
 	return w.entities[id]
 }
 
+// DeleteEntity removes an entity and its links
+func (w *worldEntities) DeleteEntity(id EntityUniqueID) bool {
+	es, ok := w.entities[id]
+	if !ok {
+		return false
+	}
+	delete(w.entities, id)
+	if w.runtimeIDs[es.RuntimeID] == id {
+		delete(w.runtimeIDs, es.RuntimeID)
+	}
+	delete(w.entityLinks, id)
+	for _, riders := range w.entityLinks {
+		delete(riders, id)
+	}
+	return true
+}
+
 func (w *worldEntities) AddEntityLink(el protocol.EntityLink) {
 	switch el.Type {
 	case protocol.EntityLinkPassenger:
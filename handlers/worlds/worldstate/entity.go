@@ -2,8 +2,11 @@ package worldstate
 
 import (
 	"math"
+	"time"
 
+	"github.com/bedrock-tool/bedrocktool/utils"
 	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/bedrock-tool/bedrocktool/utils/nbtconv"
 	"github.com/df-mc/dragonfly/server/block/cube"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/go-gl/mathgl/mgl32"
@@ -30,6 +33,13 @@ type EntityState struct {
 	Chestplate *protocol.ItemInstance
 	Leggings   *protocol.ItemInstance
 	Boots      *protocol.ItemInstance
+	Mainhand   *protocol.ItemInstance
+	Offhand    *protocol.ItemInstance
+
+	// contents of the inventory of chested mobs, set when the player opens it
+	ChestItems []protocol.ItemInstance
+	// villager trade offers from UpdateTrade
+	Offers map[string]any
 }
 
 type serverEntityType struct {
@@ -65,7 +75,8 @@ func (e serverEntity) Type() world.EntityType {
 }
 
 func (w *World) ProcessAddActor(pk *packet.AddActor, ignoreCB func(*EntityState) bool, bpCB func(behaviourpack.EntityIn)) {
-	e := w.GetEntity(pk.EntityRuntimeID)
+	// entities that left and came back get a new runtime id but keep their unique id
+	e := w.GetEntityByUniqueID(pk.EntityUniqueID)
 	if e == nil {
 		e = &EntityState{
 			RuntimeID:  pk.EntityRuntimeID,
@@ -75,6 +86,7 @@ func (w *World) ProcessAddActor(pk *packet.AddActor, ignoreCB func(*EntityState)
 			Metadata:   make(map[uint32]any),
 		}
 	}
+	e.RuntimeID = pk.EntityRuntimeID
 	e.Position = pk.Position
 	e.Pitch = pk.Pitch
 	e.Yaw = pk.Yaw
@@ -91,7 +103,8 @@ func (w *World) ProcessAddActor(pk *packet.AddActor, ignoreCB func(*EntityState)
 		return
 	}
 
-	w.StoreEntity(uint64(pk.EntityUniqueID), e)
+	w.StoreEntity(e)
+	w.RecordMove(e)
 	for _, el := range pk.EntityLinks {
 		w.AddEntityLink(el)
 	}
@@ -103,6 +116,15 @@ func (w *World) ProcessAddActor(pk *packet.AddActor, ignoreCB func(*EntityState)
 	})
 }
 
+// RecordMove adds the current position of an entity to the trajectories
+func (w *World) RecordMove(e *EntityState) {
+	if w.Trajectories == nil {
+		return
+	}
+	name, _ := e.Metadata[protocol.EntityDataKeyName].(string)
+	w.Trajectories.Move(e.UniqueID, e.EntityType, name, e.Position, e.Velocity, time.Now())
+}
+
 var flagNames = map[uint8]string{
 	protocol.EntityDataFlagSheared:      "Sheared",
 	protocol.EntityDataFlagCaptain:      "IsIllagerCaptain",
@@ -124,6 +146,14 @@ var flagNames = map[uint8]string{
 	protocol.EntityDataFlagRoaring:      "IsRoaring",
 }
 
+// chunkPos returns the chunk the entity is in, the position is floored so negative positions round down
+func (s *EntityState) chunkPos() world.ChunkPos {
+	return world.ChunkPos{
+		int32(math.Floor(float64(s.Position.X()))) >> 4,
+		int32(math.Floor(float64(s.Position.Z()))) >> 4,
+	}
+}
+
 func (s *EntityState) toNBT(nbt map[string]any) {
 	metadata := s.Metadata
 
@@ -148,6 +178,18 @@ func (s *EntityState) toNBT(nbt map[string]any) {
 	if name, ok := metadata[protocol.EntityDataKeyName]; ok {
 		nbt["CustomName"] = name
 	}
+	if poseIndex, ok := metadata[protocol.EntityDataKeyPoseIndex].(int32); ok {
+		nbt["Pose"] = map[string]any{
+			"PoseIndex":  poseIndex,
+			"LastSignal": int32(0),
+		}
+	}
+	if tradeTier, ok := metadata[protocol.EntityDataKeyTradeTier].(int32); ok {
+		nbt["TradeTier"] = tradeTier
+	}
+	if tradeExperience, ok := metadata[protocol.EntityDataKeyTradeExperience].(int32); ok {
+		nbt["TradeExperience"] = tradeExperience
+	}
 	if ShowNameTag, ok := metadata[protocol.EntityDataKeyAlwaysShowNameTag]; ok {
 		if ShowNameTag != 0 {
 			nbt["CustomNameVisible"] = true
@@ -291,7 +333,20 @@ func vec3float32(x mgl32.Vec3) []float32 {
 	return []float32{float32(x[0]), float32(x[1]), float32(x[2])}
 }
 
-func (s *EntityState) ToServerEntity(links []int64) serverEntity {
+// itemNBT converts an item from the network to the nbt stored on disk, nil is written as an empty slot
+func itemNBT(br world.BlockRegistry, it *protocol.ItemInstance) map[string]any {
+	if it == nil || it.Stack.NetworkID == 0 {
+		return map[string]any{
+			"Name":        "",
+			"Count":       byte(0),
+			"Damage":      int16(0),
+			"WasPickedUp": false,
+		}
+	}
+	return nbtconv.WriteItem(utils.StackToItem(br, it.Stack), true)
+}
+
+func (s *EntityState) ToServerEntity(links []int64, br world.BlockRegistry) serverEntity {
 	e := serverEntity{
 		EntityType: serverEntityType{
 			Encoded: s.EntityType,
@@ -316,24 +371,37 @@ func (s *EntityState) ToServerEntity(links []int64) serverEntity {
 		e.EntityType.NBT["LinksTag"] = linksTag
 	}
 
-	/*
-		if false {
-			armor := make([]map[string]any, 4)
-			if s.Helmet != nil {
-				armor[0] = nbtconv.WriteItem(utils.StackToItem(w.serverState.blocks, s.Helmet.Stack), true)
-			}
-			if s.Chestplate != nil {
-				armor[1] = nbtconv.WriteItem(utils.StackToItem(w.serverState.blocks, s.Chestplate.Stack), true)
-			}
-			if s.Leggings != nil {
-				armor[2] = nbtconv.WriteItem(utils.StackToItem(w.serverState.blocks, s.Leggings.Stack), true)
-			}
-			if s.Boots != nil {
-				armor[3] = nbtconv.WriteItem(utils.StackToItem(w.serverState.blocks, s.Boots.Stack), true)
+	if s.Helmet != nil || s.Chestplate != nil || s.Leggings != nil || s.Boots != nil {
+		e.EntityType.NBT["Armor"] = []map[string]any{
+			itemNBT(br, s.Helmet),
+			itemNBT(br, s.Chestplate),
+			itemNBT(br, s.Leggings),
+			itemNBT(br, s.Boots),
+		}
+	}
+	if s.Mainhand != nil {
+		e.EntityType.NBT["Mainhand"] = []map[string]any{itemNBT(br, s.Mainhand)}
+	}
+	if s.Offhand != nil {
+		e.EntityType.NBT["Offhand"] = []map[string]any{itemNBT(br, s.Offhand)}
+	}
+
+	if len(s.ChestItems) > 0 {
+		var chestItems []map[string]any
+		for i, it := range s.ChestItems {
+			if it.Stack.NetworkID == 0 {
+				continue
 			}
-			e.EntityType.NBT["Armor"] = armor
+			item := itemNBT(br, &it)
+			item["Slot"] = byte(i)
+			chestItems = append(chestItems, item)
 		}
-	*/
+		e.EntityType.NBT["ChestItems"] = chestItems
+	}
+
+	if s.Offers != nil {
+		e.EntityType.NBT["Offers"] = s.Offers
+	}
 
 	return e
 }
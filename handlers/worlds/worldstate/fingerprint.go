@@ -0,0 +1,98 @@
+package worldstate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/OneOfOne/xxhash"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/goleveldb/leveldb"
+)
+
+// keySubChunkData is the leveldb key tag of a sub chunk, it holds the palette and block storage
+const keySubChunkData = '/'
+
+// fingerprintDB hashes the blocks of every sub chunk in the db, the keys are iterated in order so the result
+// only depends on the blocks, time, entities and block nbt are not included.
+// the sub chunks are decoded so the order of the palette does not matter
+func fingerprintDB(ldb *leveldb.DB, br world.BlockRegistry) (string, error) {
+	h := xxhash.New64()
+	states := make(map[uint32]uint64)
+	iter := ldb.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		k := iter.Key()
+		if (len(k) != 10 && len(k) != 14) || k[len(k)-2] != keySubChunkData {
+			continue
+		}
+		index := k[len(k)-1]
+		sub, err := chunk.DecodeSubChunk(
+			bytes.NewBuffer(iter.Value()),
+			br,
+			world.Overworld.Range(), // only used for the index, which is in the key
+			&index,
+			chunk.DiskEncoding,
+			false,
+		)
+		if err != nil {
+			return "", fmt.Errorf("sub chunk %x: %w", k, err)
+		}
+		h.Write(k)
+		for _, layer := range sub.Layers() {
+			hashLayer(h, layer, br, states)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashLayer writes the state of every block in the layer, layers that are only air are skipped
+func hashLayer(h *xxhash.XXHash64, layer *chunk.PalettedStorage, br world.BlockRegistry, states map[uint32]uint64) {
+	palette := layer.Palette()
+	if palette.Len() == 1 && stateHash(palette.Value(0), br, states) == airHash {
+		return
+	}
+	var buf [8]byte
+	for x := byte(0); x < 16; x++ {
+		for y := byte(0); y < 16; y++ {
+			for z := byte(0); z < 16; z++ {
+				binary.LittleEndian.PutUint64(buf[:], stateHash(layer.At(x, y, z), br, states))
+				h.Write(buf[:])
+			}
+		}
+	}
+}
+
+var airHash = xxhash.ChecksumString64("minecraft:air")
+
+// stateHash hashes the name and properties of a block, so it doesnt depend on the runtime id
+func stateHash(rid uint32, br world.BlockRegistry, states map[uint32]uint64) uint64 {
+	if s, ok := states[rid]; ok {
+		return s
+	}
+	b, ok := br.BlockByRuntimeID(rid)
+	if !ok {
+		s := xxhash.ChecksumString64(fmt.Sprintf("unknown:%d", rid))
+		states[rid] = s
+		return s
+	}
+	name, properties := b.EncodeBlock()
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	state := name
+	for _, k := range keys {
+		state += fmt.Sprintf(",%s=%v", k, properties[k])
+	}
+	s := xxhash.ChecksumString64(state)
+	states[rid] = s
+	return s
+}
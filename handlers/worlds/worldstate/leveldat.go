@@ -0,0 +1,167 @@
+package worldstate
+
+import (
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/locale"
+	"github.com/df-mc/dragonfly/server/world/mcdb/leveldat"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sirupsen/logrus"
+)
+
+// LevelSettings is the server state that gets written to the level.dat
+type LevelSettings struct {
+	GameData  minecraft.GameData
+	StartGame *packet.StartGame
+	// current gamerules, including changes after StartGame
+	GameRules []protocol.GameRule
+	// dont force cheats, void generator and tick speed
+	Preserve bool
+}
+
+// levelDatFields maps the lowercase nbt name of every level.dat field to its index
+var levelDatFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(leveldat.Data{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("nbt"); ok {
+			name = strings.Split(tag, ",")[0]
+		}
+		fields[strings.ToLower(name)] = i
+	}
+	return fields
+}()
+
+// setField sets a level.dat field to a gamerule value, returns false if the type doesnt fit
+func setField(field reflect.Value, value any) bool {
+	v := reflect.ValueOf(value)
+	switch field.Kind() {
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return false
+		}
+		field.SetBool(b)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			field.SetInt(int64(v.Uint()))
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			field.SetInt(v.Int())
+		case reflect.Float32, reflect.Float64:
+			field.SetInt(int64(v.Float()))
+		default:
+			return false
+		}
+	case reflect.Float32, reflect.Float64:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			field.SetFloat(v.Float())
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// applyGameRules sets every gamerule that has a level.dat field,
+// the rest is returned to be written as raw keys
+func applyGameRules(ld *leveldat.Data, rules []protocol.GameRule) map[string]any {
+	raw := make(map[string]any)
+	ldv := reflect.ValueOf(ld).Elem()
+	for _, gr := range rules {
+		name := strings.ToLower(gr.Name)
+		idx, ok := levelDatFields[name]
+		if ok && setField(ldv.Field(idx), gr.Value) {
+			continue
+		}
+
+		// gamerules are stored as int32 in the level.dat
+		switch v := gr.Value.(type) {
+		case uint32:
+			raw[name] = int32(v)
+		case int32, bool, float32:
+			raw[name] = v
+		default:
+			// not a type the level.dat can hold, dropped
+			logrus.Debug(locale.Loc("unknown_gamerule", locale.Strmap{"Name": gr.Name}))
+		}
+	}
+	return raw
+}
+
+// applyStartGame copies the world settings from StartGame that have no gamerule
+func applyStartGame(ld *leveldat.Data, sg *packet.StartGame, raw map[string]any) {
+	ld.Difficulty = sg.Difficulty
+	ld.GameType = sg.WorldGameMode
+	ld.EditorWorldType = sg.EditorWorldType
+	ld.IsCreatedInEditor = sg.CreatedInEditor
+	ld.IsExportedFromEditor = sg.ExportedFromEditor
+	ld.EduOffer = sg.EducationEditionOffer
+	ld.EducationFeaturesEnabled = sg.EducationFeaturesEnabled
+	ld.ConfirmedPlatformLockedContent = sg.ConfirmedPlatformLockedContent
+	ld.MultiPlayerGame = sg.MultiPlayerGame
+	ld.LANBroadcast = sg.LANBroadcastEnabled
+	ld.XBLBroadcastMode = sg.XBLBroadcastMode
+	ld.PlatformBroadcastIntent = sg.PlatformBroadcastMode
+	ld.CommandsEnabled = sg.CommandsEnabled
+	ld.TexturePacksRequired = sg.TexturePackRequired
+	ld.BonusChestEnabled = sg.BonusChestEnabled
+	ld.StartWithMapEnabled = sg.StartWithMapEnabled
+	ld.PlayerPermissionsLevel = sg.PlayerPermissions
+	ld.ServerChunkTickRange = sg.ServerChunkTickRadius
+	ld.UseMSAGamerTagsOnly = sg.MSAGamerTagsOnly
+	ld.RainLevel = sg.RainLevel
+	ld.LightningLevel = sg.LightningLevel
+	if sg.EducationProductID != "" {
+		ld.PRID = sg.EducationProductID
+	}
+	if sg.BaseGameVersion != "" {
+		ld.BaseGameVersion = sg.BaseGameVersion
+	}
+	if sg.Hardcore {
+		raw["IsHardcore"] = true
+	}
+
+	if len(sg.Experiments) > 0 || sg.ExperimentsPreviouslyToggled {
+		if ld.Experiments == nil {
+			ld.Experiments = map[string]any{}
+		}
+		for _, e := range sg.Experiments {
+			ld.Experiments[e.Name] = e.Enabled
+		}
+		ld.Experiments["experiments_ever_used"] = true
+		ld.Experiments["saved_with_toggled_experiments"] = true
+	}
+}
+
+// writeRawLevelDat adds keys that are not in leveldat.Data to the level.dat in the folder
+func writeRawLevelDat(folder string, raw map[string]any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	filename := path.Join(folder, "level.dat")
+	ldat, err := leveldat.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var m map[string]any
+	if err = ldat.Unmarshal(&m); err != nil {
+		return err
+	}
+	for k, v := range raw {
+		m[k] = v
+	}
+	if err = ldat.Marshal(m); err != nil {
+		return err
+	}
+	return ldat.WriteFile(filename)
+}
@@ -0,0 +1,96 @@
+package worldstate
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path"
+	"slices"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"golang.org/x/exp/maps"
+)
+
+// image returns an image that draws directly into the colors of the map
+func (m *Map) image() *image.RGBA {
+	return &image.RGBA{
+		Pix:    m.Colors[:],
+		Rect:   image.Rect(0, 0, int(m.Width), int(m.Height)),
+		Stride: int(m.Width) * 4,
+	}
+}
+
+// mapDecorations converts the decorations of a map packet to the nbt stored in map_ records,
+// the tracked objects are in the same order as the decorations
+func mapDecorations(decorations []protocol.MapDecoration, tracked []protocol.MapTrackedObject) []map[string]any {
+	var out []map[string]any
+	for i, d := range decorations {
+		key := map[string]any{
+			"type": int32(protocol.MapObjectTypeBlock),
+		}
+		if i < len(tracked) {
+			t := tracked[i]
+			key["type"] = t.Type
+			switch t.Type {
+			case protocol.MapObjectTypeEntity:
+				key["id"] = t.EntityUniqueID
+			case protocol.MapObjectTypeBlock:
+				key["blockX"] = t.BlockPosition.X()
+				key["blockY"] = t.BlockPosition.Y()
+				key["blockZ"] = t.BlockPosition.Z()
+			}
+		}
+		out = append(out, map[string]any{
+			"data": map[string]any{
+				"rot":  int32(d.Rotation),
+				"type": int32(d.Type),
+				"x":    int32(d.X),
+				"y":    int32(d.Y),
+			},
+			"key": key,
+		})
+	}
+	return out
+}
+
+// saveMapImages writes every map as map_<id>.png to folder, and a contact sheet of all of them as maps.png
+func saveMapImages(folder string, mapsByID map[int64]*Map) error {
+	err := os.MkdirAll(folder, 0o755)
+	if err != nil {
+		return err
+	}
+
+	writePng := func(filename string, img image.Image) error {
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return png.Encode(f, img)
+	}
+
+	ids := maps.Keys(mapsByID)
+	slices.Sort(ids)
+
+	const padding = 4
+	cols := int(math.Ceil(math.Sqrt(float64(len(ids)))))
+	rows := (len(ids) + cols - 1) / cols
+	sheet := image.NewRGBA(image.Rect(0, 0, cols*(128+padding)+padding, rows*(128+padding)+padding))
+
+	for i, id := range ids {
+		img := mapsByID[id].image()
+		err = writePng(path.Join(folder, fmt.Sprintf("map_%d.png", id)), img)
+		if err != nil {
+			return err
+		}
+
+		x := padding + (i%cols)*(128+padding)
+		y := padding + (i/cols)*(128+padding)
+		draw.Draw(sheet, image.Rect(x, y, x+128, y+128), img, image.Point{}, draw.Over)
+	}
+
+	return writePng(path.Join(folder, "maps.png"), sheet)
+}
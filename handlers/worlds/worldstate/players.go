@@ -1,47 +1,138 @@
 package worldstate
 
 import (
+	"time"
+
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/google/uuid"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 )
 
+// player positions on the network are at eye height
+const playerEyeHeight = 1.62
+
 type player struct {
 	add                 *packet.AddPlayer
 	Position            mgl32.Vec3
 	Pitch, Yaw, HeadYaw float32
+
+	Mainhand   *protocol.ItemInstance
+	Offhand    *protocol.ItemInstance
+	Helmet     *protocol.ItemInstance
+	Chestplate *protocol.ItemInstance
+	Leggings   *protocol.ItemInstance
+	Boots      *protocol.ItemInstance
 }
 
 type worldPlayers struct {
 	players map[uuid.UUID]*player
 }
 
+// PlayerSettings controls how other players are saved as entities
+type PlayerSettings struct {
+	Save         bool
+	NoAI         bool
+	Invulnerable bool
+}
+
 func (w *World) AddPlayer(pk *packet.AddPlayer) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	heldItem := pk.HeldItem
 	w.players.players[pk.UUID] = &player{
 		add:      pk,
 		Position: pk.Position,
 		Pitch:    pk.Pitch,
 		Yaw:      pk.Yaw,
 		HeadYaw:  pk.HeadYaw,
+		Mainhand: &heldItem,
+	}
+}
+
+func (w *World) playerByRuntimeID(id uint64) *player {
+	for _, p := range w.players.players {
+		if p.add.EntityRuntimeID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// MovePlayer updates the last known position of a player
+func (w *World) MovePlayer(id uint64, pos mgl32.Vec3, pitch, yaw, headYaw float32) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	if p := w.playerByRuntimeID(id); p != nil {
+		p.Position = pos
+		p.Pitch = pitch
+		p.Yaw = yaw
+		p.HeadYaw = headYaw
+		if w.Trajectories != nil {
+			feet := pos.Sub(mgl32.Vec3{0, playerEyeHeight, 0})
+			w.Trajectories.Move(p.add.AbilityData.EntityUniqueID, "minecraft:player", p.add.Username, feet, mgl32.Vec3{}, time.Now())
+		}
+	}
+}
+
+// PlayerEquipment sets the held item of a player
+func (w *World) PlayerEquipment(pk *packet.MobEquipment) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	if p := w.playerByRuntimeID(pk.EntityRuntimeID); p != nil {
+		item := pk.NewItem
+		if pk.WindowID == protocol.WindowIDOffHand {
+			p.Offhand = &item
+		} else {
+			p.Mainhand = &item
+		}
 	}
 }
 
-func (w *World) playersToEntities() {
+// PlayerArmour sets the armour a player is wearing
+func (w *World) PlayerArmour(pk *packet.MobArmourEquipment) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	if p := w.playerByRuntimeID(pk.EntityRuntimeID); p != nil {
+		p.Helmet = &pk.Helmet
+		p.Chestplate = &pk.Chestplate
+		p.Leggings = &pk.Leggings
+		p.Boots = &pk.Boots
+	}
+}
+
+func (w *World) playersToEntities(settings PlayerSettings) []*EntityState {
+	var entities []*EntityState
 	for _, p := range w.players.players {
 		metadata := protocol.NewEntityMetadata()
 		metadata.SetFlag(protocol.EntityDataKeyFlags, protocol.EntityDataFlagAlwaysShowName)
 		metadata.SetFlag(protocol.EntityDataKeyFlags, protocol.EntityDataFlagShowName)
+		if settings.NoAI {
+			metadata.SetFlag(protocol.EntityDataKeyFlags, protocol.EntityDataFlagNoAI)
+		}
 		metadata[protocol.EntityDataKeyName] = p.add.Username
-		w.currState().StoreEntity(p.add.EntityRuntimeID, &EntityState{
+
+		pos := p.Position
+		pos[1] -= playerEyeHeight
+
+		es := &EntityState{
 			RuntimeID:  p.add.EntityRuntimeID,
-			UniqueID:   int64(p.add.EntityRuntimeID),
+			UniqueID:   p.add.AbilityData.EntityUniqueID,
 			EntityType: "player:" + p.add.UUID.String(),
-			Position:   p.Position,
+			Position:   pos,
 			Pitch:      p.Pitch,
 			Yaw:        p.Yaw,
 			HeadYaw:    p.HeadYaw,
 			Metadata:   metadata,
-		})
+			Mainhand:   p.Mainhand,
+			Offhand:    p.Offhand,
+			Helmet:     p.Helmet,
+			Chestplate: p.Chestplate,
+			Leggings:   p.Leggings,
+			Boots:      p.Boots,
+		}
+		w.memState.StoreEntity(es)
+		entities = append(entities, es)
 	}
+	return entities
 }
@@ -0,0 +1,39 @@
+package worldstate
+
+import (
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"golang.org/x/exp/maps"
+)
+
+// ChunkPositions returns the chunks that have blocks
+func (w *World) ChunkPositions() []world.ChunkPos {
+	w.l.Lock()
+	defer w.l.Unlock()
+	return maps.Keys(w.StoredChunks)
+}
+
+// ChunkBlockNBTs returns the block entities of a chunk, changes to the maps are saved
+func (w *World) ChunkBlockNBTs(pos world.ChunkPos) map[cube.Pos]map[string]any {
+	w.l.Lock()
+	defer w.l.Unlock()
+	out := make(map[cube.Pos]map[string]any)
+	for p, b := range w.memState.blockNBTs[pos] {
+		out[p] = b.NBT
+	}
+	return out
+}
+
+// EntityList returns all entities that will be saved, used by the OnSave script hook
+func (w *World) EntityList() []*EntityState {
+	w.l.Lock()
+	defer w.l.Unlock()
+	return maps.Values(w.memState.entities)
+}
+
+// RemoveEntity removes an entity so it isnt saved
+func (w *World) RemoveEntity(uniqueID EntityUniqueID) bool {
+	w.l.Lock()
+	defer w.l.Unlock()
+	return w.memState.DeleteEntity(uniqueID)
+}
@@ -0,0 +1,52 @@
+package worldstate
+
+import (
+	"github.com/bedrock-tool/bedrocktool/utils/structure"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"golang.org/x/exp/maps"
+)
+
+var _ structure.Source = &World{}
+
+// BlockNBTs returns the block entities captured in a chunk
+func (w *World) BlockNBTs(pos world.ChunkPos) (map[cube.Pos]map[string]any, error) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	out := make(map[cube.Pos]map[string]any)
+	states := []*worldStateDefer{w.memState}
+	if w.paused {
+		states = append(states, w.pausedState)
+	}
+	for _, state := range states {
+		for p, b := range state.blockNBTs[pos] {
+			out[p] = b.NBT
+		}
+	}
+	return out, nil
+}
+
+// Entities returns the nbt of the entities that are currently in a chunk
+func (w *World) Entities(pos world.ChunkPos) ([]map[string]any, error) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	var out []map[string]any
+	states := []*worldStateDefer{w.memState}
+	if w.paused {
+		states = append(states, w.pausedState)
+	}
+	for _, state := range states {
+		for _, es := range state.entities {
+			cp := es.chunkPos()
+			if cp != pos {
+				continue
+			}
+			links := maps.Keys(state.entityLinks[es.UniqueID])
+			e := es.ToServerEntity(links, w.BlockRegistry)
+			data := maps.Clone(e.EntityType.NBT)
+			data["identifier"] = e.EntityType.Encoded
+			out = append(out, data)
+		}
+	}
+	return out, nil
+}
@@ -8,8 +8,10 @@ import (
 	"sync"
 	"time"
 
-	"github.com/bedrock-tool/bedrocktool/locale"
 	"github.com/bedrock-tool/bedrocktool/utils/behaviourpack"
+	"github.com/bedrock-tool/bedrocktool/utils/chunkhistory"
+	"github.com/bedrock-tool/bedrocktool/utils/textindex"
+	"github.com/bedrock-tool/bedrocktool/utils/trajectory"
 	"github.com/df-mc/dragonfly/server/block/cube"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/df-mc/dragonfly/server/world/chunk"
@@ -17,7 +19,6 @@ import (
 	"github.com/df-mc/goleveldb/leveldb"
 	"github.com/df-mc/goleveldb/leveldb/opt"
 	"github.com/google/uuid"
-	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
@@ -28,7 +29,7 @@ import (
 type worldStateInterface interface {
 	StoreChunk(pos world.ChunkPos, ch *chunk.Chunk, blockNBT map[cube.Pos]DummyBlock) error
 	SetBlockNBT(pos cube.Pos, nbt map[string]any, merge bool)
-	StoreEntity(id EntityRuntimeID, es *EntityState)
+	StoreEntity(es *EntityState)
 	GetEntity(id EntityRuntimeID) *EntityState
 	AddEntityLink(el protocol.EntityLink)
 }
@@ -58,16 +59,40 @@ type World struct {
 	err    error
 
 	players worldPlayers
+	weather weather
+
+	// text of signs, books and items seen in this world
+	TextIndex *textindex.Index
 
 	VoidGen  bool
 	timeSync time.Time
 	time     int
 	Name     string
 	Folder   string
+
+	// keep every distinct version of the chunks in a folder next to the world
+	KeepHistory bool
+	history     *chunkhistory.Writer
+
+	// where entities moved, nil when not recorded
+	Trajectories *trajectory.Recorder
+	// what happens to entities the server removes
+	DespawnPolicy DespawnPolicy
+
+	// hash of all sub chunks, set by Finish
+	Fingerprint string
+	// when capturing this world started
+	Started time.Time
+}
+
+type weather struct {
+	set                       bool
+	rainLevel, lightningLevel float32
+	rainTime, lightningTime   int32
 }
 
 type Map struct {
-	Decorations       []any            `nbt:"decorations"`
+	Decorations       []map[string]any `nbt:"decorations"`
 	Dimension         uint8            `nbt:"dimension"`
 	Height            int16            `nbt:"height"`
 	Width             int16            `nbt:"width"`
@@ -88,18 +113,17 @@ func New(cf func(world.ChunkPos, *chunk.Chunk), dimensionDefinitions map[int]pro
 		dimensionDefinitions: dimensionDefinitions,
 		finish:               make(chan struct{}),
 		memState: &worldStateDefer{
-			chunks: make(map[world.ChunkPos]*chunk.Chunk),
-			worldEntities: worldEntities{
-				entities:    make(map[EntityRuntimeID]*EntityState),
-				entityLinks: make(map[EntityUniqueID]map[EntityUniqueID]struct{}),
-				blockNBTs:   make(map[world.ChunkPos]map[cube.Pos]DummyBlock),
-			},
+			chunks:        make(map[world.ChunkPos]*chunk.Chunk),
+			maps:          make(map[int64]*Map),
+			worldEntities: newWorldEntities(),
 		},
 		players: worldPlayers{
 			players: make(map[uuid.UUID]*player),
 		},
 		BlockRegistry: br,
 		BiomeRegistry: br2,
+		TextIndex:     textindex.New(),
+		Started:       time.Now(),
 	}
 
 	return w, nil
@@ -179,6 +203,26 @@ func (w *World) SetTime(real time.Time, ingame int) {
 	w.time = ingame
 }
 
+// SetWeather stores the weather from LevelEvent, intensity is 0-65535 like the packet
+func (w *World) SetWeather(thunder bool, intensity int32) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	w.weather.set = true
+	level := float32(intensity) / 65535
+	// time until the weather changes, the server doesnt tell so use a day
+	var duration int32
+	if intensity > 0 {
+		duration = 24000
+	}
+	if thunder {
+		w.weather.lightningLevel = level
+		w.weather.lightningTime = duration
+	} else {
+		w.weather.rainLevel = level
+		w.weather.rainTime = duration
+	}
+}
+
 func (w *World) StoreChunk(pos world.ChunkPos, ch *chunk.Chunk, blockNBT map[cube.Pos]DummyBlock) (err error) {
 	w.l.Lock()
 	defer w.l.Unlock()
@@ -192,6 +236,11 @@ func (w *World) StoreChunk(pos world.ChunkPos, ch *chunk.Chunk, blockNBT map[cub
 	}
 	if !empty {
 		w.StoredChunks[pos] = true
+		if w.history != nil {
+			if err := w.history.Add(w.dimension, pos, ch, time.Now()); err != nil {
+				logrus.Errorf("chunk history: %s", err)
+			}
+		}
 	}
 
 	w.currState().StoreChunk(pos, ch, blockNBT)
@@ -199,6 +248,19 @@ func (w *World) StoreChunk(pos world.ChunkPos, ch *chunk.Chunk, blockNBT map[cub
 	return nil
 }
 
+// DropChunk forgets a chunk that is still in memory, used when a script ignores it
+func (w *World) DropChunk(pos world.ChunkPos) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	delete(w.StoredChunks, pos)
+	for _, state := range []*worldStateDefer{w.memState, w.pausedState} {
+		if state != nil {
+			delete(state.chunks, pos)
+			delete(state.blockNBTs, pos)
+		}
+	}
+}
+
 func (w *World) LoadChunk(pos world.ChunkPos) (*chunk.Chunk, bool, error) {
 	w.l.Lock()
 	defer w.l.Unlock()
@@ -235,10 +297,10 @@ func (w *World) SetBlockNBT(pos cube.Pos, nbt map[string]any, merge bool) {
 	w.currState().SetBlockNBT(pos, nbt, merge)
 }
 
-func (w *World) StoreEntity(id EntityRuntimeID, es *EntityState) {
+func (w *World) StoreEntity(es *EntityState) {
 	w.l.Lock()
 	defer w.l.Unlock()
-	w.currState().StoreEntity(id, es)
+	w.currState().StoreEntity(es)
 }
 
 func (w *World) StoreMap(m *packet.ClientBoundMapItemData) {
@@ -256,7 +318,19 @@ func (w *World) GetEntity(id EntityRuntimeID) *EntityState {
 			return es
 		}
 	}
-	return w.memState.entities[id]
+	return w.memState.GetEntity(id)
+}
+
+// GetEntityByUniqueID looks up an entity by its unique id, used by packets that dont have the runtime id
+func (w *World) GetEntityByUniqueID(id EntityUniqueID) *EntityState {
+	w.l.Lock()
+	defer w.l.Unlock()
+	if w.paused {
+		if es := w.pausedState.GetEntityByUniqueID(id); es != nil {
+			return es
+		}
+	}
+	return w.memState.GetEntityByUniqueID(id)
 }
 
 func (w *World) EntityCount() int {
@@ -273,12 +347,9 @@ func (w *World) PauseCapture() {
 	w.l.Lock()
 	w.paused = true
 	w.pausedState = &worldStateDefer{
-		chunks: make(map[world.ChunkPos]*chunk.Chunk),
-		worldEntities: worldEntities{
-			entities:    make(map[EntityRuntimeID]*EntityState),
-			entityLinks: make(map[EntityUniqueID]map[EntityUniqueID]struct{}),
-			blockNBTs:   make(map[world.ChunkPos]map[cube.Pos]DummyBlock),
-		},
+		chunks:        make(map[world.ChunkPos]*chunk.Chunk),
+		maps:          make(map[int64]*Map),
+		worldEntities: newWorldEntities(),
 	}
 	w.l.Unlock()
 }
@@ -308,6 +379,9 @@ func (w *World) Open(name string, folder string, deferred bool) {
 		panic("trying to open already opened world")
 	}
 	w.opened = true
+	if w.KeepHistory {
+		w.history = chunkhistory.NewWriter(folder + chunkhistory.Suffix)
+	}
 
 	if w.paused && !deferred {
 		w.pausedState.ApplyTo(w, cube.Pos{}, -1, w.ChunkFunc)
@@ -354,22 +428,33 @@ func (w *World) Rename(name, folder string) error {
 			return w.err
 		}
 	}
+	if w.history != nil {
+		if err := w.history.Rename(folder + chunkhistory.Suffix); err != nil {
+			return err
+		}
+	}
 	w.Folder = folder
 	w.Name = name
 	return nil
 }
 
-func (w *World) Finish(playerData map[string]any, excludedMobs []string, withPlayers bool, spawn cube.Pos, gd minecraft.GameData, bp *behaviourpack.Pack) error {
+func (w *World) Finish(playerData map[string]any, excludedMobs []string, players PlayerSettings, spawn cube.Pos, level LevelSettings, bp *behaviourpack.Pack) error {
 	w.l.Lock()
 	defer w.l.Unlock()
 	close(w.finish)
 
-	if withPlayers {
-		w.playersToEntities()
+	if w.history != nil {
+		if err := w.history.Close(); err != nil {
+			logrus.Errorf("chunk history: %s", err)
+		}
+	}
 
-		for _, p := range w.players.players {
+	if players.Save {
+		for _, es := range w.playersToEntities(players) {
 			bp.AddEntity(behaviourpack.EntityIn{
-				Identifier: "player:" + p.add.UUID.String(),
+				Identifier: es.EntityType,
+				Meta:       es.Metadata,
+				Vulnerable: !players.Invulnerable,
 			})
 		}
 	}
@@ -392,9 +477,9 @@ func (w *World) Finish(playerData map[string]any, excludedMobs []string, withPla
 			}
 		}
 		if !ignore {
-			cp := world.ChunkPos{int32(es.Position.X()) >> 4, int32(es.Position.Z()) >> 4}
+			cp := es.chunkPos()
 			links := maps.Keys(w.memState.entityLinks[es.UniqueID])
-			chunkEntities[cp] = append(chunkEntities[cp], es.ToServerEntity(links))
+			chunkEntities[cp] = append(chunkEntities[cp], es.ToServerEntity(links, w.BlockRegistry))
 		}
 	}
 
@@ -422,6 +507,13 @@ func (w *World) Finish(playerData map[string]any, excludedMobs []string, withPla
 	}
 
 	ldb := w.provider.LDB()
+	if len(w.memState.maps) > 0 {
+		// next to the world, map ids are only unique in one world
+		err = saveMapImages(w.Folder+".maps", w.memState.maps)
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
 	for id, m := range w.memState.maps {
 		d, err := nbt.MarshalEncoding(m, nbt.LittleEndian)
 		if err != nil {
@@ -437,95 +529,64 @@ func (w *World) Finish(playerData map[string]any, excludedMobs []string, withPla
 	s := w.provider.Settings()
 	s.Spawn = spawn
 	s.Name = w.Name
+	gd := level.GameData
 
 	// set gamerules
 	ld := w.provider.LevelDat()
-	ld.CheatsEnabled = true
 	ld.RandomSeed = int64(gd.WorldSeed)
-	for _, gr := range gd.GameRules {
-		switch gr.Name {
-		case "commandblockoutput":
-			ld.CommandBlockOutput = gr.Value.(bool)
-		case "maxcommandchainlength":
-			ld.MaxCommandChainLength = int32(gr.Value.(uint32))
-		case "commandblocksenabled":
-			//ld.CommandsEnabled = gr.Value.(bool)
-		case "dodaylightcycle":
-			ld.DoDayLightCycle = gr.Value.(bool)
-		case "doentitydrops":
-			ld.DoEntityDrops = gr.Value.(bool)
-		case "dofiretick":
-			ld.DoFireTick = gr.Value.(bool)
-		case "domobloot":
-			ld.DoMobLoot = gr.Value.(bool)
-		case "domobspawning":
-			ld.DoMobSpawning = gr.Value.(bool)
-		case "dotiledrops":
-			ld.DoTileDrops = gr.Value.(bool)
-		case "doweathercycle":
-			ld.DoWeatherCycle = gr.Value.(bool)
-		case "drowningdamage":
-			ld.DrowningDamage = gr.Value.(bool)
-		case "doinsomnia":
-			ld.DoInsomnia = gr.Value.(bool)
-		case "falldamage":
-			ld.FallDamage = gr.Value.(bool)
-		case "firedamage":
-			ld.FireDamage = gr.Value.(bool)
-		case "keepinventory":
-			ld.KeepInventory = gr.Value.(bool)
-		case "mobgriefing":
-			ld.MobGriefing = gr.Value.(bool)
-		case "pvp":
-			ld.PVP = gr.Value.(bool)
-		case "showcoordinates":
-			ld.ShowCoordinates = gr.Value.(bool)
-		case "naturalregeneration":
-			ld.NaturalRegeneration = gr.Value.(bool)
-		case "tntexplodes":
-			ld.TNTExplodes = gr.Value.(bool)
-		case "sendcommandfeedback":
-			ld.SendCommandFeedback = gr.Value.(bool)
-		case "randomtickspeed":
-			ld.RandomTickSpeed = int32(gr.Value.(uint32))
-		case "doimmediaterespawn":
-			ld.DoImmediateRespawn = gr.Value.(bool)
-		case "showdeathmessages":
-			ld.ShowDeathMessages = gr.Value.(bool)
-		case "functioncommandlimit":
-			ld.FunctionCommandLimit = int32(gr.Value.(uint32))
-		case "spawnradius":
-			ld.SpawnRadius = int32(gr.Value.(uint32))
-		case "showtags":
-			ld.ShowTags = gr.Value.(bool)
-		case "freezedamage":
-			ld.FreezeDamage = gr.Value.(bool)
-		case "respawnblocksexplode":
-			ld.RespawnBlocksExplode = gr.Value.(bool)
-		case "showbordereffect":
-			ld.ShowBorderEffect = gr.Value.(bool)
-		// todo
-		default:
-			logrus.Warnf(locale.Loc("unknown_gamerule", locale.Strmap{"Name": gr.Name}))
+	gameRules := level.GameRules
+	if gameRules == nil {
+		gameRules = gd.GameRules
+	}
+	raw := applyGameRules(ld, gameRules)
+	if level.StartGame != nil {
+		applyStartGame(ld, level.StartGame, raw)
+		if level.Preserve {
+			ws := level.StartGame.WorldSpawn
+			s.Spawn = cube.Pos{int(ws.X()), int(ws.Y()), int(ws.Z())}
 		}
 	}
 
-	// void world
-	if w.VoidGen {
-		ld.FlatWorldLayers = `{"biome_id":1,"block_layers":[{"block_data":0,"block_id":0,"count":1},{"block_data":0,"block_id":0,"count":2},{"block_data":0,"block_id":0,"count":1}],"encoding_version":3,"structure_options":null}`
-		ld.Generator = 2
+	if w.weather.set {
+		ld.RainLevel = w.weather.rainLevel
+		ld.LightningLevel = w.weather.lightningLevel
+		ld.RainTime = w.weather.rainTime
+		ld.LightningTime = w.weather.lightningTime
+	}
+
+	if !level.Preserve {
+		ld.CheatsEnabled = true
+		ld.RandomTickSpeed = 0
+
+		// void world
+		if w.VoidGen {
+			ld.FlatWorldLayers = `{"biome_id":1,"block_layers":[{"block_data":0,"block_id":0,"count":1},{"block_data":0,"block_id":0,"count":2},{"block_data":0,"block_id":0,"count":1}],"encoding_version":3,"structure_options":null}`
+			ld.Generator = 2
+		}
 	}
 
-	ld.RandomTickSpeed = 0
 	s.CurrentTick = gd.Time
 
 	ticksSince := int64(time.Since(w.timeSync)/time.Millisecond) / 50
 	s.Time = int64(w.time)
+	s.TimeCycle = ld.DoDayLightCycle
 	if ld.DoDayLightCycle {
 		s.Time += ticksSince
-		s.TimeCycle = true
 	}
 
+	// SaveSettings overwrites these in the level.dat
+	if mode, ok := world.GameModeByID(int(ld.GameType)); ok {
+		s.DefaultGameMode = mode
+	} else {
+		// default and spectator are not registered, SaveSettings would write survival
+		raw["GameType"] = ld.GameType
+	}
+	s.Difficulty, _ = world.DifficultyByID(int(ld.Difficulty))
+	s.WeatherCycle = ld.DoWeatherCycle
+	s.TickRange = ld.ServerChunkTickRange
+	s.Raining, s.RainTime = ld.RainLevel > 0, int64(ld.RainTime)
+	s.Thundering, s.ThunderTime = ld.LightningLevel > 0, int64(ld.LightningTime)
+
 	if bp.HasContent() {
 		if ld.Experiments == nil {
 			ld.Experiments = map[string]any{}
@@ -536,5 +597,14 @@ func (w *World) Finish(playerData map[string]any, excludedMobs []string, withPla
 	}
 
 	w.provider.SaveSettings(s)
-	return w.provider.Close()
+
+	w.Fingerprint, err = fingerprintDB(ldb, w.BlockRegistry)
+	if err != nil {
+		logrus.Warnf("fingerprint: %s", err)
+	}
+	err = w.provider.Close()
+	if err != nil {
+		return err
+	}
+	return writeRawLevelDat(w.Folder, raw)
 }
@@ -1,3 +1,5 @@
+// Code generated by generate-scripting-types. DO NOT EDIT.
+
 declare const console: {
     log(data: any);
 };
@@ -5,21 +7,95 @@ declare const console: {
 declare type WindowID = number;
 declare type Slot = number;
 
-declare type Entity = {
-    RuntimeID: number
-    UniqueID: number
-    EntityType: string
+declare type ChunkPos = [number, number];
+declare type BlockPos = [number, number, number];
 
+declare type Block = {
+    name: string;
+    properties: {[k: string]: any};
+};
+
+/** x and z can be in the chunk (0-15) or world coordinates */
+declare type Chunk = {
+    pos: ChunkPos;
+    getBlock(x: number, y: number, z: number, layer?: number): Block | null;
+    setBlock(x: number, y: number, z: number, name: string, properties?: {[k: string]: any}, layer?: number): boolean;
+    blockNBT(x: number, y: number, z: number): {[k: string]: any} | null;
+    biome(x: number, y: number, z: number): string | null;
+    height(x: number, z: number): number;
+};
+
+/** the world in OnSave, changes are written to the saved world */
+declare type SaveWorld = {
+    name: string;
+    dimension: number;
+    chunks(): Array<ChunkPos>;
+    getChunk(x: number, z: number): Chunk | null;
+    entities(): Array<Entity>;
+    removeEntity(uniqueID: number): boolean;
+};
+
+declare type CommandParamType = "int" | "float" | "value" | "target" | "string" | "block" | "position" | "message" | "text" | "json";
+
+declare type CommandParam = {
+    name: string;
+    type: CommandParamType;
+    optional?: boolean;
+};
+
+/**
+ * adds an ingame command, fn gets the words typed after the command,
+ * a returned string is sent to the player as a chat message
+ */
+declare function registerCommand(name: string, params: Array<CommandParam>, fn: (args: Array<string>) => string | void, description?: string): void;
+
+/** key value store that is kept across sessions, in a json file next to the script */
+declare const storage: {
+    get(key: string): any;
+    set(key: string, value: any): void;
+    delete(key: string): void;
+    keys(): Array<string>;
+};
+
+/** loads a script relative to this one, like commonjs. modules share the runtime and storage of the script */
+declare function require(path: string): any;
+declare var module: { exports: any };
+declare var exports: any;
+declare var __filename: string;
+declare var __dirname: string;
+
+declare type EntityMetadata = {
+    [k: EntityDataKey]: any;
+    SetFlag: (key: EntityDataKey, index: EntityDataFlag) => void;
+    Flag: (key: EntityDataKey, index: EntityDataFlag) => boolean;
+};
+
+declare type Entity = {
+    RuntimeID: number;
+    UniqueID: number;
+    EntityType: string;
     Position: [number, number, number];
     Pitch: number;
     Yaw: number;
+    HeadYaw: number;
     Velocity: [number, number, number];
-}
+    HasMoved: boolean;
+    Metadata: EntityMetadata;
+    Inventory: {[k: number]: {[k: number]: ItemInstance}};
+    Helmet: ItemInstance | null;
+    Chestplate: ItemInstance | null;
+    Leggings: ItemInstance | null;
+    Boots: ItemInstance | null;
+    Mainhand: ItemInstance | null;
+    Offhand: ItemInstance | null;
+    ChestItems: Array<ItemInstance>;
+    Offers: {[k: string]: any};
+};
 
 declare type ItemInstance = {
     StackNetworkID: number;
     Stack: ItemStack;
-}
+};
 
 declare type ItemStack = {
     NetworkID: number;
@@ -30,266 +106,268 @@ declare type ItemStack = {
     CanBePlacedOn: Array<string>;
     CanBreak: Array<string>;
     HasNetworkID: boolean;
-}
-
-declare type ChunkPos = [number, number];
-
-
-
-declare type EntityMetadata = {
-    [k: EntityDataKey]: any;
-    SetFlag: (key: EntityDataKey, index: EntityDataFlag) => void;
-    Flag: (key: EntityDataKey, index: EntityDataFlag) => boolean;
 };
 
+/** the functions a script can define, bedrocktool calls them */
+declare interface Callbacks {
+    OnEntityAdd?(entity: Entity, metadata: EntityMetadata): boolean;
+    OnChunkAdd?(pos: ChunkPos, chunk: Chunk): boolean;
+    OnEntityDataUpdate?(entity: Entity, metadata: EntityMetadata): void;
+    OnBlockNBT?(pos: BlockPos, nbt: {[k: string]: any}): boolean;
+    OnSave?(world: SaveWorld): void;
+}
+
 declare enum EntityDataKey {
-	Flags,
-	StructuralIntegrity,
-	Variant,
-	ColorIndex,
-	Name,
-	Owner,
-	Target,
-	AirSupply,
-	EffectColor,
-	EffectAmbience,
-	JumpDuration,
-	Hurt,
-	HurtDirection,
-	RowTimeLeft,
-	RowTimeRight,
-	Value,
-	DisplayTileRuntimeID,
-	DisplayOffset,
-	CustomDisplay,
-	Swell,
-	OldSwell,
-	SwellDirection,
-	ChargeAmount,
-	CarryBlockRuntimeID,
-	ClientEvent,
-	UsingItem,
-	PlayerFlags,
-	PlayerIndex,
-	BedPosition,
-	PowerX,
-	PowerY,
-	PowerZ,
-	AuxPower,
-	FishX,
-	FishZ,
-	FishAngle,
-	AuxValueData,
-	LeashHolder,
-	Scale,
-	HasNPC,
-	NPCData,
-	Actions,
-	AirSupplyMax,
-	MarkVariant,
-	ContainerType,
-	ContainerSize,
-	ContainerStrengthModifier,
-	BlockTarget,
-	Inventory,
-	TargetA,
-	TargetB,
-	TargetC,
-	AerialAttack,
-	Width,
-	Height,
-	FuseTime,
-	SeatOffset,
-	SeatLockPassengerRotation,
-	SeatLockPassengerRotationDegrees,
-	SeatRotationOffset,
-	SeatRotationOffstDegrees,
-	DataRadius,
-	DataWaiting,
-	DataParticle,
-	PeekID,
-	AttachFace,
-	Attached,
-	AttachedPosition,
-	TradeTarget,
-	Career,
-	HasCommandBlock,
-	CommandName,
-	LastCommandOutput,
-	TrackCommandOutput,
-	ControllingSeatIndex,
-	Strength,
-	StrengthMax,
-	DataSpellCastingColor,
-	DataLifetimeTicks,
-	PoseIndex,
-	DataTickOffset,
-	AlwaysShowNameTag,
-	ColorTwoIndex,
-	NameAuthor,
-	Score,
-	BalloonAnchor,
-	PuffedState,
-	BubbleTime,
-	Agent,
-	SittingAmount,
-	SittingAmountPrevious,
-	EatingCounter,
-	FlagsTwo,
-	LayingAmount,
-	LayingAmountPrevious,
-	DataDuration,
-	DataSpawnTime,
-	DataChangeRate,
-	DataChangeOnPickup,
-	DataPickupCount,
-	InteractText,
-	TradeTier,
-	MaxTradeTier,
-	TradeExperience,
-	SkinID,
-	SpawningFrames,
-	CommandBlockTickDelay,
-	CommandBlockExecuteOnFirstTick,
-	AmbientSoundInterval,
-	AmbientSoundIntervalRange,
-	AmbientSoundEventName,
-	FallDamageMultiplier,
-	NameRawText,
-	CanRideTarget,
-	LowTierCuredTradeDiscount,
-	HighTierCuredTradeDiscount,
-	NearbyCuredTradeDiscount,
-	NearbyCuredDiscountTimeStamp,
-	HitBox,
-	IsBuoyant,
-	FreezingEffectStrength,
-	BuoyancyData,
-	GoatHornCount,
-	BaseRuntimeID,
-	MovementSoundDistanceOffset,
-	HeartbeatIntervalTicks,
-	HeartbeatSoundEvent,
-	PlayerLastDeathPosition,
-	PlayerLastDeathDimension,
-	PlayerHasDied,
-	CollisionBox,
-};
+    Flags = 0,
+    StructuralIntegrity = 1,
+    Variant = 2,
+    ColorIndex = 3,
+    Name = 4,
+    Owner = 5,
+    Target = 6,
+    AirSupply = 7,
+    EffectColor = 8,
+    EffectAmbience = 9,
+    JumpDuration = 10,
+    Hurt = 11,
+    HurtDirection = 12,
+    RowTimeLeft = 13,
+    RowTimeRight = 14,
+    Value = 15,
+    DisplayTileRuntimeID = 16,
+    DisplayOffset = 17,
+    CustomDisplay = 18,
+    Swell = 19,
+    OldSwell = 20,
+    SwellDirection = 21,
+    ChargeAmount = 22,
+    CarryBlockRuntimeID = 23,
+    ClientEvent = 24,
+    UsingItem = 25,
+    PlayerFlags = 26,
+    PlayerIndex = 27,
+    BedPosition = 28,
+    PowerX = 29,
+    PowerY = 30,
+    PowerZ = 31,
+    AuxPower = 32,
+    FishX = 33,
+    FishZ = 34,
+    FishAngle = 35,
+    AuxValueData = 36,
+    LeashHolder = 37,
+    Scale = 38,
+    HasNPC = 39,
+    NPCData = 40,
+    Actions = 41,
+    AirSupplyMax = 42,
+    MarkVariant = 43,
+    ContainerType = 44,
+    ContainerSize = 45,
+    ContainerStrengthModifier = 46,
+    BlockTarget = 47,
+    Inventory = 48,
+    TargetA = 49,
+    TargetB = 50,
+    TargetC = 51,
+    AerialAttack = 52,
+    Width = 53,
+    Height = 54,
+    FuseTime = 55,
+    SeatOffset = 56,
+    SeatLockPassengerRotation = 57,
+    SeatLockPassengerRotationDegrees = 58,
+    SeatRotationOffset = 59,
+    SeatRotationOffstDegrees = 60,
+    DataRadius = 61,
+    DataWaiting = 62,
+    DataParticle = 63,
+    PeekID = 64,
+    AttachFace = 65,
+    Attached = 66,
+    AttachedPosition = 67,
+    TradeTarget = 68,
+    Career = 69,
+    HasCommandBlock = 70,
+    CommandName = 71,
+    LastCommandOutput = 72,
+    TrackCommandOutput = 73,
+    ControllingSeatIndex = 74,
+    Strength = 75,
+    StrengthMax = 76,
+    DataSpellCastingColor = 77,
+    DataLifetimeTicks = 78,
+    PoseIndex = 79,
+    DataTickOffset = 80,
+    AlwaysShowNameTag = 81,
+    ColorTwoIndex = 82,
+    NameAuthor = 83,
+    Score = 84,
+    BalloonAnchor = 85,
+    PuffedState = 86,
+    BubbleTime = 87,
+    Agent = 88,
+    SittingAmount = 89,
+    SittingAmountPrevious = 90,
+    EatingCounter = 91,
+    FlagsTwo = 92,
+    LayingAmount = 93,
+    LayingAmountPrevious = 94,
+    DataDuration = 95,
+    DataSpawnTime = 96,
+    DataChangeRate = 97,
+    DataChangeOnPickup = 98,
+    DataPickupCount = 99,
+    InteractText = 100,
+    TradeTier = 101,
+    MaxTradeTier = 102,
+    TradeExperience = 103,
+    SkinID = 104,
+    SpawningFrames = 105,
+    CommandBlockTickDelay = 106,
+    CommandBlockExecuteOnFirstTick = 107,
+    AmbientSoundInterval = 108,
+    AmbientSoundIntervalRange = 109,
+    AmbientSoundEventName = 110,
+    FallDamageMultiplier = 111,
+    NameRawText = 112,
+    CanRideTarget = 113,
+    LowTierCuredTradeDiscount = 114,
+    HighTierCuredTradeDiscount = 115,
+    NearbyCuredTradeDiscount = 116,
+    NearbyCuredDiscountTimeStamp = 117,
+    HitBox = 118,
+    IsBuoyant = 119,
+    FreezingEffectStrength = 120,
+    BuoyancyData = 121,
+    GoatHornCount = 122,
+    BaseRuntimeID = 123,
+    MovementSoundDistanceOffset = 124,
+    HeartbeatIntervalTicks = 125,
+    HeartbeatSoundEvent = 126,
+    PlayerLastDeathPosition = 127,
+    PlayerLastDeathDimension = 128,
+    PlayerHasDied = 129,
+    CollisionBox = 130,
+}
 
 declare enum EntityDataFlag {
-    OnFire,
-	Sneaking,
-	Riding,
-	Sprinting,
-	UsingItem,
-	Invisible,
-	Tempted,
-	InLove,
-	Saddled,
-	Powered,
-	Ignited,
-	Baby,
-	Converting,
-	Critical,
-	ShowName,
-	AlwaysShowName,
-	NoAI,
-	Silent,
-	WallClimbing,
-	Climb,
-	Swim,
-	Fly,
-	Walk,
-	Resting,
-	Sitting,
-	Angry,
-	Interested,
-	Charged,
-	Tamed,
-	Orphaned,
-	Leashed,
-	Sheared,
-	Gliding,
-	Elder,
-	Moving,
-	Breathing,
-	Chested,
-	Stackable,
-	ShowBottom,
-	Standing,
-	Shaking,
-	Idling,
-	Casting,
-	Charging,
-	KeyboardControlled,
-	PowerJump,
-	Dash,
-	Lingering,
-	HasCollision,
-	HasGravity,
-	FireImmune,
-	Dancing,
-	Enchanted,
-	ReturnTrident,
-	ContainerPrivate,
-	Transforming,
-	DamageNearbyMobs,
-	Swimming,
-	Bribed,
-	Pregnant,
-	LayingEgg,
-	PassengerCanPick,
-	TransitionSitting,
-	Eating,
-	LayingDown,
-	Sneezing,
-	Trusting,
-	Rolling,
-	Scared,
-	InScaffolding,
-	OverScaffolding,
-	DescendThroughBlock,
-	Blocking,
-	TransitionBlocking,
-	BlockedUsingShield,
-	BlockedUsingDamagedShield,
-	Sleeping,
-	WantsToWake,
-	TradeInterest,
-	DoorBreaker,
-	BreakingObstruction,
-	DoorOpener,
-	Captain,
-	Stunned,
-	Roaring,
-	DelayedAttack,
-	AvoidingMobs,
-	AvoidingBlock,
-	FacingTargetToRangeAttack,
-	HiddenWhenInvisible,
-	InUI,
-	Stalking,
-	Emoting,
-	Celebrating,
-	Admiring,
-	CelebratingSpecial,
-	OutOfControl,
-	RamAttack,
-	PlayingDead,
-	InAscendingBlock,
-	OverDescendingBlock,
-	Croaking,
-	DigestMob,
-	JumpGoal,
-	Emerging,
-	Sniffing,
-	Digging,
-	SonicBoom,
-	HasDashTimeout,
-	PushTowardsClosestSpace,
-	Scenting,
-	Rising,
-	FeelingHappy,
-	Searching,
-	Crawling,
+    OnFire = 0,
+    Sneaking = 1,
+    Riding = 2,
+    Sprinting = 3,
+    UsingItem = 4,
+    Invisible = 5,
+    Tempted = 6,
+    InLove = 7,
+    Saddled = 8,
+    Powered = 9,
+    Ignited = 10,
+    Baby = 11,
+    Converting = 12,
+    Critical = 13,
+    ShowName = 14,
+    AlwaysShowName = 15,
+    NoAI = 16,
+    Silent = 17,
+    WallClimbing = 18,
+    Climb = 19,
+    Swim = 20,
+    Fly = 21,
+    Walk = 22,
+    Resting = 23,
+    Sitting = 24,
+    Angry = 25,
+    Interested = 26,
+    Charged = 27,
+    Tamed = 28,
+    Orphaned = 29,
+    Leashed = 30,
+    Sheared = 31,
+    Gliding = 32,
+    Elder = 33,
+    Moving = 34,
+    Breathing = 35,
+    Chested = 36,
+    Stackable = 37,
+    ShowBottom = 38,
+    Standing = 39,
+    Shaking = 40,
+    Idling = 41,
+    Casting = 42,
+    Charging = 43,
+    KeyboardControlled = 44,
+    PowerJump = 45,
+    Dash = 46,
+    Lingering = 47,
+    HasCollision = 48,
+    HasGravity = 49,
+    FireImmune = 50,
+    Dancing = 51,
+    Enchanted = 52,
+    ReturnTrident = 53,
+    ContainerPrivate = 54,
+    Transforming = 55,
+    DamageNearbyMobs = 56,
+    Swimming = 57,
+    Bribed = 58,
+    Pregnant = 59,
+    LayingEgg = 60,
+    PassengerCanPick = 61,
+    TransitionSitting = 62,
+    Eating = 63,
+    LayingDown = 64,
+    Sneezing = 65,
+    Trusting = 66,
+    Rolling = 67,
+    Scared = 68,
+    InScaffolding = 69,
+    OverScaffolding = 70,
+    DescendThroughBlock = 71,
+    Blocking = 72,
+    TransitionBlocking = 73,
+    BlockedUsingShield = 74,
+    BlockedUsingDamagedShield = 75,
+    Sleeping = 76,
+    WantsToWake = 77,
+    TradeInterest = 78,
+    DoorBreaker = 79,
+    BreakingObstruction = 80,
+    DoorOpener = 81,
+    Captain = 82,
+    Stunned = 83,
+    Roaring = 84,
+    DelayedAttack = 85,
+    AvoidingMobs = 86,
+    AvoidingBlock = 87,
+    FacingTargetToRangeAttack = 88,
+    HiddenWhenInvisible = 89,
+    InUI = 90,
+    Stalking = 91,
+    Emoting = 92,
+    Celebrating = 93,
+    Admiring = 94,
+    CelebratingSpecial = 95,
+    OutOfControl = 96,
+    RamAttack = 97,
+    PlayingDead = 98,
+    InAscendingBlock = 99,
+    OverDescendingBlock = 100,
+    Croaking = 101,
+    DigestMob = 102,
+    JumpGoal = 103,
+    Emerging = 104,
+    Sniffing = 105,
+    Digging = 106,
+    SonicBoom = 107,
+    HasDashTimeout = 108,
+    PushTowardsClosestSpace = 109,
+    Scenting = 110,
+    Rising = 111,
+    FeelingHappy = 112,
+    Searching = 113,
+    Crawling = 114,
+    TimerFlag1 = 115,
+    TimerFlag2 = 116,
+    TimerFlag3 = 117,
 }
@@ -1,3 +1,13 @@
+// count the worlds saved with this script, kept across sessions
+registerCommand("saved-count", [], (args) => {
+    return `saved ${storage.get("saved") || 0} worlds with this script`;
+}, "how many worlds were saved with this script");
+
+registerCommand("note", [{name: "text", type: "message"}], (args) => {
+    storage.set("note", args.join(" "));
+    return "note saved";
+});
+
 /**
  * @param {Entity} entity
  * @param {EntityMetadata} data
@@ -12,9 +22,10 @@ function OnEntityAdd(entity, data) {
 
 /**
  * @param {ChunkPos} pos
+ * @param {Chunk} chunk
  * @returns {boolean} ignore chunk
  */
-function OnChunkAdd(pos) {
+function OnChunkAdd(pos, chunk) {
     return false;
 }
 
@@ -25,4 +36,41 @@ function OnChunkAdd(pos) {
 function OnEntityDataUpdate(entity, data) {
     console.log("OnEntityDataUpdate");
     console.log("entity name: "+data[EntityDataKey.Name]);
-}
\ No newline at end of file
+}
+/**
+ * @param {BlockPos} pos
+ * @param {{[k: string]: any}} nbt
+ * @returns {boolean} ignore block nbt
+ */
+function OnBlockNBT(pos, nbt) {
+    return false;
+}
+
+/**
+ * @param {SaveWorld} world
+ */
+function OnSave(world) {
+    storage.set("saved", (storage.get("saved") || 0) + 1);
+
+    // replace barriers with air
+    for (const pos of world.chunks()) {
+        const chunk = world.getChunk(pos[0], pos[1]);
+        for (let x = 0; x < 16; x++) {
+            for (let z = 0; z < 16; z++) {
+                for (let y = -64; y <= chunk.height(x, z); y++) {
+                    const block = chunk.getBlock(x, y, z);
+                    if (block && block.name == "minecraft:barrier") {
+                        chunk.setBlock(x, y, z, "minecraft:air");
+                    }
+                }
+            }
+        }
+    }
+
+    // remove named armour stands, servers use them for holograms
+    for (const entity of world.entities()) {
+        if (entity.EntityType == "minecraft:armor_stand" && entity.Metadata[EntityDataKey.Name]) {
+            world.removeEntity(entity.UniqueID);
+        }
+    }
+}
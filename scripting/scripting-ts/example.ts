@@ -1,3 +1,9 @@
+import { isHologram } from "./holograms";
+
+registerCommand("saved-count", [], (args) => {
+    return `saved ${storage.get("saved") || 0} worlds with this script`;
+}, "how many worlds were saved with this script");
+
 
 function OnEntityAdd(entity: Entity, data: EntityMetadata): boolean {
     console.log("adding entity " + entity.EntityType);
@@ -6,7 +12,7 @@ function OnEntityAdd(entity: Entity, data: EntityMetadata): boolean {
     return false;
 }
 
-function OnChunkAdd(pos: ChunkPos): boolean {
+function OnChunkAdd(pos: ChunkPos, chunk: Chunk): boolean {
     return false;
 }
 
@@ -14,3 +20,17 @@ function OnEntityDataUpdate(entity: Entity, data: EntityMetadata) {
     console.log("OnEntityDataUpdate");
     console.log("entity name: "+data[EntityDataKey.Name]);
 }
+
+function OnBlockNBT(pos: BlockPos, nbt: {[k: string]: any}): boolean {
+    return false;
+}
+
+function OnSave(world: SaveWorld) {
+    storage.set("saved", (storage.get("saved") || 0) + 1);
+    for (const entity of world.entities()) {
+        if (isHologram(entity)) {
+            world.removeEntity(entity.UniqueID);
+        }
+    }
+    console.log(`saving ${world.name} with ${world.chunks().length} chunks`);
+}
@@ -0,0 +1,4 @@
+// servers show floating text with named invisible armour stands
+export function isHologram(entity: Entity): boolean {
+    return entity.EntityType == "minecraft:armor_stand" && !!entity.Metadata[EntityDataKey.Name];
+}
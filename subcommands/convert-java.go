@@ -0,0 +1,82 @@
+package subcommands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/javaworld"
+	"github.com/bedrock-tool/bedrocktool/utils/mcworld"
+	"github.com/sirupsen/logrus"
+)
+
+type ConvertJavaCMD struct {
+	World    string
+	Out      string
+	Fallback string
+}
+
+func (*ConvertJavaCMD) Name() string { return "convert-java" }
+func (*ConvertJavaCMD) Synopsis() string {
+	return "convert a saved world to a java edition world"
+}
+func (c *ConvertJavaCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.World, "world", "", "world folder or .mcworld file")
+	f.StringVar(&c.Out, "out", "", "output folder, default is the world name with -java")
+	f.StringVar(&c.Fallback, "fallback", "", "json file mapping bedrock block names to java blocks, * for all unknown custom blocks")
+}
+
+func (c *ConvertJavaCMD) Execute(ctx context.Context) error {
+	if c.World == "" {
+		return errors.New("-world is required")
+	}
+	out := c.Out
+	if out == "" {
+		out = strings.TrimSuffix(filepath.Clean(c.World), ".mcworld") + "-java"
+	}
+
+	var fallback javaworld.FallbackTable
+	if c.Fallback != "" {
+		var err error
+		fallback, err = javaworld.ReadFallbackTable(c.Fallback)
+		if err != nil {
+			return err
+		}
+	}
+
+	w, err := mcworld.Open(c.World, nil)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	err = os.MkdirAll(out, 0o777)
+	if err != nil {
+		return err
+	}
+	t, err := javaworld.Convert(w, out, fallback)
+	if err != nil {
+		return err
+	}
+
+	// write the blocks that used a fallback so they can be edited and passed with -fallback
+	if len(t.Unmapped) > 0 {
+		data, _ := json.MarshalIndent(t.Unmapped, "", "\t")
+		filename := filepath.Join(out, "unmapped_blocks.json")
+		if err = os.WriteFile(filename, data, 0o644); err != nil {
+			return err
+		}
+		logrus.Warnf("%d custom blocks were replaced, see %s", len(t.Unmapped), filename)
+	}
+	logrus.Infof("Wrote java world to %s", out)
+	return nil
+}
+
+func init() {
+	commands.RegisterCommand(&ConvertJavaCMD{})
+}
@@ -0,0 +1,220 @@
+package subcommands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/bedrock-tool/bedrocktool/utils/chunkhistory"
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/mcworld"
+	"github.com/bedrock-tool/bedrocktool/utils/render"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sirupsen/logrus"
+)
+
+type RenderCMD struct {
+	World     string
+	Out       string
+	Mode      string
+	CaveY     int
+	TileSize  int
+	Dimension int
+	Tiles     bool
+	Timelapse bool
+	Area      string
+	Interval  int
+	Gif       bool
+}
+
+func (*RenderCMD) Name() string     { return "render" }
+func (*RenderCMD) Synopsis() string { return "render a saved world to png" }
+func (c *RenderCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.World, "world", "", "world folder or .mcworld file")
+	f.StringVar(&c.Out, "out", "", "output folder, default is the world name with -render")
+	f.StringVar(&c.Mode, "mode", render.ModeTop, "top, cave or height")
+	f.IntVar(&c.CaveY, "cave-y", 32, "y level of the slice in cave mode")
+	f.IntVar(&c.TileSize, "tile-size", 32, "size of the rendered tiles in chunks")
+	f.IntVar(&c.Dimension, "dimension", -1, "dimension id to render, -1 for all")
+	f.BoolVar(&c.Tiles, "tiles", false, "write a zoomable tile pyramid and an index.html viewer instead of one image")
+	f.BoolVar(&c.Timelapse, "timelapse", false, "render frames from the chunk history of a world captured with -chunk-history")
+	f.StringVar(&c.Area, "area", "", "time-lapse area in blocks x1,z1,x2,z2, default is everything")
+	f.IntVar(&c.Interval, "interval", 60, "seconds of changes per time-lapse frame")
+	f.BoolVar(&c.Gif, "gif", true, "also write the time-lapse as an animated gif")
+}
+
+func (c *RenderCMD) Execute(ctx context.Context) error {
+	if c.World == "" {
+		return errors.New("-world is required")
+	}
+	switch c.Mode {
+	case render.ModeTop, render.ModeCave, render.ModeHeight:
+	default:
+		return fmt.Errorf("unknown mode %s", c.Mode)
+	}
+	if c.Tiles && c.Timelapse {
+		return errors.New("-tiles and -timelapse cant be used together")
+	}
+	out := c.Out
+	if out == "" {
+		out = strings.TrimSuffix(filepath.Clean(c.World), ".mcworld") + "-render"
+	}
+
+	w, err := mcworld.Open(c.World, nil)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	// colors of custom blocks from the packs saved with the world
+	utils.ResolveColors(w.CustomBlocks, w.ResourcePacks(), true)
+
+	dimensions := []world.Dimension{world.Overworld, world.Nether, world.End}
+	if c.Dimension != -1 {
+		dim, ok := world.DimensionByID(c.Dimension)
+		if !ok {
+			return fmt.Errorf("unknown dimension %d", c.Dimension)
+		}
+		dimensions = []world.Dimension{dim}
+	}
+
+	if c.Tiles {
+		return c.renderTiles(ctx, w, out, dimensions)
+	}
+	if c.Timelapse {
+		return c.renderTimelapse(ctx, w, out, dimensions)
+	}
+
+	opts := render.Options{
+		Mode:     c.Mode,
+		CaveY:    c.CaveY,
+		TileSize: c.TileSize,
+	}
+	for _, dim := range dimensions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		w.Dimension = dim
+		name := strings.ToLower(fmt.Sprint(dim))
+		tiles, err := render.RenderTiles(w, filepath.Join(out, name), opts)
+		if err != nil {
+			return err
+		}
+		if len(tiles.Tiles) == 0 {
+			continue
+		}
+		filename := filepath.Join(out, name+".png")
+		if err = tiles.Stitch(filename); err != nil {
+			return err
+		}
+		logrus.Infof("Rendered %s to %s", name, filename)
+	}
+	return nil
+}
+
+// renderTiles writes tiles/<dimension>/z/x/y.png and the viewer
+func (c *RenderCMD) renderTiles(ctx context.Context, w *mcworld.World, out string, dimensions []world.Dimension) error {
+	configs := make(map[string]render.DimensionConfig)
+	markers := make(map[string][]render.Marker)
+	for _, dim := range dimensions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		w.Dimension = dim
+		name := strings.ToLower(fmt.Sprint(dim))
+		p, err := render.RenderPyramid(w, filepath.Join(out, "tiles", name), render.Options{
+			Mode:  c.Mode,
+			CaveY: c.CaveY,
+		})
+		if err != nil {
+			return err
+		}
+		if len(p.Tiles.Tiles) == 0 {
+			continue
+		}
+		configs[name] = render.DimensionConfig{
+			Levels: p.Levels,
+			Min:    p.Tiles.Min,
+			Max:    p.Tiles.Max,
+		}
+		markers[name], err = render.ReadMarkers(w)
+		if err != nil {
+			return err
+		}
+		logrus.Infof("Rendered %s with %d levels, %d markers", name, p.Levels+1, len(markers[name]))
+	}
+
+	err := render.WriteViewer(out, configs, markers)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Open %s to view the map", filepath.Join(out, "index.html"))
+	return nil
+}
+
+// parseArea parses x1,z1,x2,z2 in blocks to the chunks it covers
+func parseArea(s string) (lo, hi world.ChunkPos, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return lo, hi, fmt.Errorf("invalid area %q, expected x1,z1,x2,z2", s)
+	}
+	var v [4]int32
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return lo, hi, fmt.Errorf("invalid area %q: %w", s, err)
+		}
+		v[i] = int32(n) >> 4
+	}
+	lo = world.ChunkPos{min(v[0], v[2]), min(v[1], v[3])}
+	hi = world.ChunkPos{max(v[0], v[2]), max(v[1], v[3])}
+	return lo, hi, nil
+}
+
+// renderTimelapse writes timelapse/<dimension>/frame_00000.png from the history folder next to the world
+func (c *RenderCMD) renderTimelapse(ctx context.Context, w *mcworld.World, out string, dimensions []world.Dimension) error {
+	historyFolder := strings.TrimSuffix(filepath.Clean(c.World), ".mcworld") + chunkhistory.Suffix
+	if _, err := os.Stat(historyFolder); err != nil {
+		return fmt.Errorf("no chunk history at %s, capture with -chunk-history", historyFolder)
+	}
+	opts := render.TimelapseOptions{
+		Options:  render.Options{Mode: c.Mode, CaveY: c.CaveY},
+		Interval: time.Duration(c.Interval) * time.Second,
+		Gif:      c.Gif,
+	}
+	if c.Area != "" {
+		var err error
+		opts.AreaMin, opts.AreaMax, err = parseArea(c.Area)
+		if err != nil {
+			return err
+		}
+		opts.HasArea = true
+	}
+	for _, dim := range dimensions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		opts.Dimension = dim
+		name := strings.ToLower(fmt.Sprint(dim))
+		folder := filepath.Join(out, "timelapse", name)
+		frames, err := render.RenderTimelapse(historyFolder, folder, w.Blocks, opts)
+		if err != nil {
+			return err
+		}
+		if frames > 0 {
+			logrus.Infof("Rendered %d %s frames to %s", frames, name, folder)
+		}
+	}
+	return nil
+}
+
+func init() {
+	commands.RegisterCommand(&RenderCMD{})
+}
@@ -0,0 +1,68 @@
+package subcommands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/textindex"
+)
+
+type SearchCMD struct {
+	Query string
+	Dir   string
+	Type  string
+	Regex bool
+}
+
+func (*SearchCMD) Name() string { return "search" }
+func (*SearchCMD) Synopsis() string {
+	return "search the text of signs, books and items in saved worlds"
+}
+func (c *SearchCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.Query, "query", "", "text to search for, case insensitive")
+	f.StringVar(&c.Dir, "dir", "worlds", "folder with the saved worlds")
+	f.StringVar(&c.Type, "type", "", "only search this type (sign, book, lectern, command, container_name, item_name, item_lore)")
+	f.BoolVar(&c.Regex, "regex", false, "the query is a regular expression")
+}
+
+func (c *SearchCMD) Execute(ctx context.Context) error {
+	if c.Query == "" {
+		return errors.New("-query is required")
+	}
+	var matchText func(string) bool
+	if c.Regex {
+		re, err := regexp.Compile("(?i)" + c.Query)
+		if err != nil {
+			return err
+		}
+		matchText = re.MatchString
+	} else {
+		query := strings.ToLower(c.Query)
+		matchText = func(s string) bool {
+			return strings.Contains(strings.ToLower(s), query)
+		}
+	}
+
+	var count int
+	err := textindex.Search(c.Dir, func(e textindex.Entry) bool {
+		return (c.Type == "" || e.Type == c.Type) && matchText(e.Text)
+	}, func(world string, e textindex.Entry) {
+		count++
+		text := strings.ReplaceAll(e.Text, "\n", " | ")
+		fmt.Printf("%s %s %d %d %d [%s] %s\n", world, e.Dimension, e.Pos[0], e.Pos[1], e.Pos[2], e.Type, text)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d results\n", count)
+	return nil
+}
+
+func init() {
+	commands.RegisterCommand(&SearchCMD{})
+}
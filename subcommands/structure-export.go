@@ -0,0 +1,95 @@
+package subcommands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/mcworld"
+	"github.com/bedrock-tool/bedrocktool/utils/structure"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sirupsen/logrus"
+)
+
+type StructureExportCMD struct {
+	World     string
+	Dimension int
+	From      string
+	To        string
+	Out       string
+}
+
+func (*StructureExportCMD) Name() string { return "structure-export" }
+func (*StructureExportCMD) Synopsis() string {
+	return "export a region of a saved world as .mcstructure"
+}
+func (c *StructureExportCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.World, "world", "", "world folder or .mcworld file")
+	f.IntVar(&c.Dimension, "dimension", 0, "dimension id, 0 overworld, 1 nether, 2 end")
+	f.StringVar(&c.From, "from", "", "first corner x,y,z")
+	f.StringVar(&c.To, "to", "", "second corner x,y,z")
+	f.StringVar(&c.Out, "out", "structure.mcstructure", "output file")
+}
+
+func parseBlockPos(s string) (pos cube.Pos, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return pos, fmt.Errorf("invalid position %q, expected x,y,z", s)
+	}
+	for i, p := range parts {
+		pos[i], err = strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return pos, fmt.Errorf("invalid position %q: %w", s, err)
+		}
+	}
+	return pos, nil
+}
+
+func (c *StructureExportCMD) Execute(ctx context.Context) error {
+	if c.World == "" {
+		return errors.New("-world is required")
+	}
+	from, err := parseBlockPos(c.From)
+	if err != nil {
+		return err
+	}
+	to, err := parseBlockPos(c.To)
+	if err != nil {
+		return err
+	}
+	dim, ok := world.DimensionByID(c.Dimension)
+	if !ok {
+		return fmt.Errorf("unknown dimension %d", c.Dimension)
+	}
+
+	w, err := mcworld.Open(c.World, nil)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	w.Dimension = dim
+
+	s, err := structure.Export(w, from, to)
+	if err != nil {
+		return err
+	}
+	out := c.Out
+	if !strings.HasSuffix(out, ".mcstructure") {
+		out += ".mcstructure"
+	}
+	err = s.WriteFile(out)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Wrote %dx%dx%d structure to %s", s.Size[0], s.Size[1], s.Size[2], out)
+	return nil
+}
+
+func init() {
+	commands.RegisterCommand(&StructureExportCMD{})
+}
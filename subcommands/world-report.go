@@ -0,0 +1,57 @@
+package subcommands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"path/filepath"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/report"
+	"github.com/sirupsen/logrus"
+)
+
+type WorldReportCMD struct {
+	World string
+	Out   string
+}
+
+func (*WorldReportCMD) Name() string { return "world-report" }
+func (*WorldReportCMD) Synopsis() string {
+	return "count blocks, ores, entities, containers and texts of a saved world"
+}
+func (c *WorldReportCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.World, "world", "", "world folder or .mcworld file")
+	f.StringVar(&c.Out, "out", "", "output file, default is the world name with .report.json")
+}
+
+func (c *WorldReportCMD) Execute(ctx context.Context) error {
+	if c.World == "" {
+		return errors.New("-world is required")
+	}
+	base := strings.TrimSuffix(filepath.Clean(c.World), ".mcworld")
+	out := c.Out
+	if out == "" {
+		out = base + ".report.json"
+	}
+
+	r, err := report.GenerateFile(c.World, filepath.Base(base), out, nil)
+	if err != nil {
+		return err
+	}
+	for dim, d := range r.Dimensions {
+		var entities int
+		for _, e := range d.Entities {
+			entities += e.Count
+		}
+		logrus.Infof("%s: %d chunks, %d block types, %d entities, %d containers, %d texts",
+			dim, d.Chunks, len(d.Blocks), entities, len(d.Containers), len(d.Texts))
+	}
+	logrus.Infof("Wrote %s", out)
+	return nil
+}
+
+func init() {
+	commands.RegisterCommand(&WorldReportCMD{})
+}
@@ -3,28 +3,46 @@ package world
 import (
 	"context"
 	"flag"
-	"os"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bedrock-tool/bedrocktool/handlers/worlds"
+	"github.com/bedrock-tool/bedrocktool/handlers/worlds/scripting"
+	"github.com/bedrock-tool/bedrocktool/handlers/worlds/worldstate"
 	"github.com/bedrock-tool/bedrocktool/locale"
+	"github.com/bedrock-tool/bedrocktool/utils"
 	"github.com/bedrock-tool/bedrocktool/utils/commands"
 	"github.com/bedrock-tool/bedrocktool/utils/proxy"
+	"github.com/bedrock-tool/bedrocktool/utils/trajectory"
 )
 
 type WorldCMD struct {
-	ServerAddress   string
-	ListenAddress   string
-	Packs           bool
-	EnableVoid      bool
-	SaveEntities    bool
-	SaveInventories bool
-	SaveImage       bool
-	ExcludeMobs     string
-	StartPaused     bool
-	PreloadReplay   string
-	ChunkRadius     int
-	ScriptPath      string
+	ServerAddress    string
+	ListenAddress    string
+	Packs            bool
+	EnableVoid       bool
+	SaveEntities     bool
+	SaveInventories  bool
+	SaveImage        bool
+	ExcludeMobs      string
+	StartPaused      bool
+	PreloadReplay    string
+	ChunkRadius      int
+	ScriptPath       string
+	ScriptTimeout    int
+	ScriptMemory     int
+	Dedupe           string
+	SavePlayers      bool
+	PlayersNoAI      bool
+	PlayersInvuln    bool
+	PreserveSettings bool
+	WebMap           string
+	ChunkHistory     bool
+	Trajectories     string
+	TrajectoryTypes  string
+	Despawn          string
+	ServerData       bool
 }
 
 func (*WorldCMD) Name() string     { return "worlds" }
@@ -42,19 +60,43 @@ func (c *WorldCMD) SetFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.StartPaused, "start-paused", false, "pause the capturing on startup (can be restarted using /start-capture ingame)")
 	f.StringVar(&c.PreloadReplay, "preload-replay", "", "preload from a replay")
 	f.IntVar(&c.ChunkRadius, "chunk-radius", 0, "the max chunk radius to force")
-	f.StringVar(&c.ScriptPath, "script", "", "path to script to use")
+	f.StringVar(&c.ScriptPath, "script", "", "scripts or folders of scripts to use seperated by comma, reloaded when a file changes")
+	f.IntVar(&c.ScriptTimeout, "script-timeout", int(scripting.DefaultLimits.Timeout/time.Millisecond), "milliseconds a script callback can run before it is stopped")
+	f.IntVar(&c.ScriptMemory, "script-memory", int(scripting.DefaultLimits.MaxMemory>>20), "megabytes a script callback can allocate, 0 for no limit")
+	f.BoolVar(&c.SavePlayers, "save-players", false, "save other players as npc entities with their skin")
+	f.BoolVar(&c.PlayersNoAI, "players-noai", false, "saved players dont move")
+	f.BoolVar(&c.PlayersInvuln, "players-invulnerable", false, "saved players cant be damaged")
+	f.BoolVar(&c.PreserveSettings, "preserve-settings", false, "keep the servers settings, dont enable cheats, void generator or stop random ticks")
+	f.StringVar(&c.WebMap, "web-map", "", "serve a live map in the browser on this address, example :8080")
+	f.BoolVar(&c.ChunkHistory, "chunk-history", false, "keep every version of the chunks next to the world, for render -timelapse")
+	f.StringVar(&c.Trajectories, "trajectories", "", "record where entities move and save it next to the world as csv or geojson")
+	f.StringVar(&c.TrajectoryTypes, "trajectory-types", "", "entity types to record trajectories of seperated by comma, all when empty")
+	f.StringVar(&c.Despawn, "despawn", worldstate.DespawnKeepLastSeen, "what to do with entities the server removes (keep-last-seen, drop-on-remove, keep-if-persistent), per type like keep-if-persistent,item=drop-on-remove")
+	f.BoolVar(&c.ServerData, "server-data", false, "save the creative inventory and commands of the server next to the world")
+	f.StringVar(&c.Dedupe, "dedupe", worlds.DedupeOff, "what to do with worlds identical to an earlier one (off, skip, link)")
 }
 
 func (c *WorldCMD) Execute(ctx context.Context) error {
-	var script string
+	var scripts []string
 	if c.ScriptPath != "" {
-		data, err := os.ReadFile(c.ScriptPath)
-		if err != nil {
-			return err
-		}
-		script = string(data)
+		scripts = strings.Split(c.ScriptPath, ",")
 	}
 
+	switch c.Trajectories {
+	case "", trajectory.FormatCSV, trajectory.FormatGeoJSON:
+	default:
+		return fmt.Errorf("-trajectories has to be %s or %s", trajectory.FormatCSV, trajectory.FormatGeoJSON)
+	}
+
+	despawnPolicy, err := worldstate.ParseDespawnPolicy(c.Despawn)
+	if err != nil {
+		return fmt.Errorf("-despawn: %w", err)
+	}
+
+	scriptLimits := scripting.DefaultLimits
+	scriptLimits.Timeout = time.Duration(c.ScriptTimeout) * time.Millisecond
+	scriptLimits.MaxMemory = uint64(c.ScriptMemory) << 20
+
 	proxy, err := proxy.New(true)
 	if err != nil {
 		return err
@@ -66,16 +108,29 @@ func (c *WorldCMD) Execute(ctx context.Context) error {
 	proxy.ListenAddress = c.ListenAddress
 
 	proxy.AddHandler(worlds.NewWorldsHandler(worlds.WorldSettings{
-		VoidGen:         c.EnableVoid,
-		WithPacks:       c.Packs,
-		SaveEntities:    c.SaveEntities,
-		SaveInventories: c.SaveInventories,
-		SaveImage:       c.SaveImage,
-		ExcludedMobs:    strings.Split(c.ExcludeMobs, ","),
-		StartPaused:     c.StartPaused,
-		PreloadReplay:   c.PreloadReplay,
-		ChunkRadius:     int32(c.ChunkRadius),
-		Script:          script,
+		VoidGen:          c.EnableVoid,
+		WithPacks:        c.Packs,
+		SaveEntities:     c.SaveEntities,
+		SaveInventories:  c.SaveInventories,
+		SaveImage:        c.SaveImage,
+		ExcludedMobs:     strings.Split(c.ExcludeMobs, ","),
+		StartPaused:      c.StartPaused,
+		PreloadReplay:    c.PreloadReplay,
+		ChunkRadius:      int32(c.ChunkRadius),
+		Scripts:          scripts,
+		ScriptLimits:     scriptLimits,
+		Dedupe:           c.Dedupe,
+		Players:          c.SavePlayers,
+		PlayersNoAI:      c.PlayersNoAI,
+		PlayersInvuln:    c.PlayersInvuln,
+		PreserveSettings: c.PreserveSettings,
+		WebMap:           c.WebMap,
+		ChunkHistory:     c.ChunkHistory,
+		Trajectories:     c.Trajectories,
+		TrajectoryTypes:  strings.Split(c.TrajectoryTypes, ","),
+		DespawnPolicy:    despawnPolicy,
+		ServerData:       c.ServerData,
+		RealmID:          utils.RealmID(c.ServerAddress),
 	}))
 
 	err = proxy.Run(ctx, c.ServerAddress)
@@ -0,0 +1,133 @@
+package subcommands
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bedrock-tool/bedrocktool/utils/commands"
+	"github.com/bedrock-tool/bedrocktool/utils/worldmeta"
+)
+
+type WorldsListCMD struct {
+	Dir       string
+	Server    string
+	After     string
+	Before    string
+	MinChunks int
+	Sort      string
+	JSON      bool
+}
+
+func (*WorldsListCMD) Name() string { return "worlds-list" }
+func (*WorldsListCMD) Synopsis() string {
+	return "list the saved worlds with their server, capture time and size"
+}
+func (c *WorldsListCMD) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.Dir, "dir", "worlds", "folder with the saved worlds")
+	f.StringVar(&c.Server, "server", "", "only worlds from servers with this in their name or address")
+	f.StringVar(&c.After, "after", "", "only worlds captured after this date, 2006-01-02")
+	f.StringVar(&c.Before, "before", "", "only worlds captured before this date, 2006-01-02")
+	f.IntVar(&c.MinChunks, "min-chunks", 0, "only worlds with at least this many chunks")
+	f.StringVar(&c.Sort, "sort", "start", "sort by start, name or chunks")
+	f.BoolVar(&c.JSON, "json", false, "print the metadata as json instead of a table")
+}
+
+type listedWorld struct {
+	Path string `json:"path"`
+	*worldmeta.Metadata
+}
+
+func parseDateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return t, fmt.Errorf("-%s: %w", name, err)
+	}
+	return t, nil
+}
+
+func (c *WorldsListCMD) Execute(ctx context.Context) error {
+	after, err := parseDateFlag("after", c.After)
+	if err != nil {
+		return err
+	}
+	before, err := parseDateFlag("before", c.Before)
+	if err != nil {
+		return err
+	}
+	var less func(a, b listedWorld) bool
+	switch c.Sort {
+	case "start":
+		less = func(a, b listedWorld) bool { return a.CaptureStart.Before(b.CaptureStart) }
+	case "name":
+		less = func(a, b listedWorld) bool { return a.Path < b.Path }
+	case "chunks":
+		less = func(a, b listedWorld) bool { return a.Chunks() > b.Chunks() }
+	default:
+		return fmt.Errorf("-sort has to be start, name or chunks")
+	}
+	server := strings.ToLower(c.Server)
+
+	var worlds []listedWorld
+	err = worldmeta.Scan(c.Dir, func(world string, m *worldmeta.Metadata) {
+		if server != "" && !strings.Contains(strings.ToLower(m.Server.Name), server) && !strings.Contains(strings.ToLower(m.Server.Address), server) {
+			return
+		}
+		if !after.IsZero() && m.CaptureStart.Before(after) {
+			return
+		}
+		if !before.IsZero() && !m.CaptureStart.Before(before) {
+			return
+		}
+		if m.Chunks() < c.MinChunks {
+			return
+		}
+		worlds = append(worlds, listedWorld{Path: world, Metadata: m})
+	})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(worlds, func(i, j int) bool { return less(worlds[i], worlds[j]) })
+
+	if c.JSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "\t")
+		if worlds == nil {
+			worlds = []listedWorld{}
+		}
+		return e.Encode(worlds)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORLD\tSERVER\tSTARTED\tDURATION\tCHUNKS\tENTITIES\tVERSION\tPACKS")
+	for _, w := range worlds {
+		serverName := w.Server.Name
+		if w.Server.RealmID != "" {
+			serverName += " (realm " + w.Server.RealmID + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%d\n",
+			w.Path, serverName,
+			w.CaptureStart.Local().Format("2006-01-02 15:04"),
+			w.CaptureEnd.Sub(w.CaptureStart).Round(time.Second),
+			w.Chunks(), w.Entities, w.GameVersion, len(w.Packs),
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d worlds\n", len(worlds))
+	return nil
+}
+
+func init() {
+	commands.RegisterCommand(&WorldsListCMD{})
+}
@@ -166,9 +166,13 @@ func (m *Map2) Update(u *messages.UpdateMap) {
 			img = image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
 			m.images[tilePos] = img
 		}
+		var src image.Image = image.Transparent
+		if chunk, ok := u.Chunks[cp]; ok {
+			src = chunk
+		}
 		draw.Draw(img, image.Rectangle{
 			Min: posInTile, Max: posInTile.Add(image.Pt(16, 16)),
-		}, u.Chunks[cp], image.Point{}, draw.Src)
+		}, src, image.Point{}, draw.Src)
 		updatedTiles = append(updatedTiles, tilePos)
 	}
 
@@ -3,6 +3,8 @@ package behaviourpack
 type biomeBehaviour struct {
 	FormatVersion  string         `json:"format_version"`
 	MinecraftBiome MinecraftBiome `json:"minecraft:biome"`
+	// goes in the resource pack, not here
+	waterColour string
 }
 
 type biomeDescription struct {
@@ -11,19 +13,78 @@ type biomeDescription struct {
 
 type MinecraftBiome struct {
 	Description biomeDescription `json:"description"`
+	Components  map[string]any   `json:"components"`
 }
 
-func (b *Pack) AddBiomes(biomesMap map[string]any) {
-	for name, biome := range biomesMap {
-		_ = biome
-		b.biomes = append(b.biomes, biomeBehaviour{
-			FormatVersion: "1.13.0",
-			MinecraftBiome: MinecraftBiome{
-				Description: biomeDescription{
-					Identifier: name,
-				},
+type BiomeIn struct {
+	Identifier  string
+	Temperature float64
+	Downfall    float64
+	Tags        []string
+	// #rrggbb, empty when the server didnt send it
+	WaterColour   string
+	GrassColour   string
+	FoliageColour string
+}
+
+func (bp *Pack) AddBiome(biome BiomeIn) {
+	ns, _ := ns_name_split(biome.Identifier)
+	if ns == "minecraft" {
+		return
+	}
+
+	formatVersion := "1.13.0"
+	components := map[string]any{
+		"minecraft:climate": map[string]any{
+			"temperature": biome.Temperature,
+			"downfall":    biome.Downfall,
+		},
+	}
+
+	tints := map[string]any{}
+	if biome.GrassColour != "" {
+		tints["grass"] = biome.GrassColour
+	}
+	if biome.FoliageColour != "" {
+		tints["foliage"] = biome.FoliageColour
+	}
+	if len(tints) > 0 {
+		// map_tints needs the newer format, which has tags in minecraft:tags
+		formatVersion = "1.21.70"
+		components["minecraft:map_tints"] = tints
+		if len(biome.Tags) > 0 {
+			components["minecraft:tags"] = map[string]any{"tags": biome.Tags}
+		}
+	} else {
+		// in this format tags are empty components
+		for _, tag := range biome.Tags {
+			components[tag] = map[string]any{}
+		}
+	}
+
+	bp.biomes[biome.Identifier] = &biomeBehaviour{
+		FormatVersion: formatVersion,
+		MinecraftBiome: MinecraftBiome{
+			Description: biomeDescription{
+				Identifier: biome.Identifier,
 			},
-		})
+			Components: components,
+		},
+		waterColour: biome.WaterColour,
 	}
+}
 
+func (bp *Pack) HasBiomes() bool {
+	return len(bp.biomes) > 0
+}
+
+// BiomeWaterColours returns the water colour of every custom biome that has one, for biomes_client.json
+func (bp *Pack) BiomeWaterColours() map[string]string {
+	colours := make(map[string]string)
+	for name, b := range bp.biomes {
+		if b.waterColour != "" {
+			colours[name] = b.waterColour
+		}
+	}
+	return colours
 }
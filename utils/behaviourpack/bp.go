@@ -18,7 +18,10 @@ type Pack struct {
 	blocks        map[string]*blockBehaviour
 	items         map[string]*itemBehaviour
 	entities      map[string]*entityBehaviour
-	biomes        []biomeBehaviour
+	biomes        map[string]*biomeBehaviour
+	recipes       map[string]*recipeBehaviour
+	// names of all items by network id, for recipes
+	itemNames map[int32]string
 }
 
 func New(name string) *Pack {
@@ -44,9 +47,12 @@ func New(name string) *Pack {
 			Dependencies: []resource.Dependency{},
 			Capabilities: []resource.Capability{},
 		},
-		blocks:   make(map[string]*blockBehaviour),
-		items:    make(map[string]*itemBehaviour),
-		entities: make(map[string]*entityBehaviour),
+		blocks:    make(map[string]*blockBehaviour),
+		items:     make(map[string]*itemBehaviour),
+		entities:  make(map[string]*entityBehaviour),
+		biomes:    make(map[string]*biomeBehaviour),
+		recipes:   make(map[string]*recipeBehaviour),
+		itemNames: make(map[int32]string),
 	}
 }
 
@@ -64,12 +70,14 @@ func (bp *Pack) CheckAddLink(pack utils.Pack) {
 		return
 	}
 
+	// textures and geometry the block components refer to
 	var hasBlocksJson = false
 	if bp.HasBlocks() {
 		_, hasBlocksJson = slices.BinarySearch(names, "blocks.json")
-		if err == nil {
-			hasBlocksJson = true
-		}
+		_, hasTerrainTexture := slices.BinarySearch(names, "textures/terrain_texture.json")
+		i, _ := slices.BinarySearch(names, "models/blocks/")
+		hasBlockModels := i < len(names) && strings.HasPrefix(names[i], "models/blocks/")
+		hasBlocksJson = hasBlocksJson || hasTerrainTexture || hasBlockModels
 	}
 
 	var hasEntitiesFolder = false
@@ -104,7 +112,7 @@ func (bp *Pack) HasEntities() bool {
 }
 
 func (bp *Pack) HasContent() bool {
-	return bp.HasBlocks() || bp.HasItems()
+	return bp.HasBlocks() || bp.HasItems() || bp.HasBiomes() || bp.HasRecipes()
 }
 
 func ns_name_split(identifier string) (ns, name string) {
@@ -167,6 +175,26 @@ func (bp *Pack) Save(fpath string) error {
 			}
 		}
 	}
+	if bp.HasBiomes() { // biomes
+		biomesDir := filepath.Join(fpath, "biomes")
+		_ = os.Mkdir(biomesDir, 0o755)
+		for _, bb := range bp.biomes {
+			err := _add_thing(biomesDir, bb.MinecraftBiome.Description.Identifier, bb)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if bp.HasRecipes() { // recipes
+		recipesDir := filepath.Join(fpath, "recipes")
+		_ = os.Mkdir(recipesDir, 0o755)
+		for id, rb := range bp.recipes {
+			err := _add_thing(recipesDir, id, rb)
+			if err != nil {
+				return err
+			}
+		}
+	}
 
 	return nil
 }
@@ -1,24 +1,33 @@
 package behaviourpack
 
-import "github.com/sandertv/gophertunnel/minecraft/protocol"
+import (
+	"math"
+	"sort"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
 
 type description struct {
-	Identifier             string         `json:"identifier"`
-	IsExperimental         bool           `json:"is_experimental"`
-	RegisterToCreativeMenu bool           `json:"register_to_creative_menu"`
-	Properties             map[string]any `json:"properties,omitempty"`
-	MenuCategory           menuCategory   `json:"menu_category,omitempty"`
+	Identifier   string         `json:"identifier"`
+	States       map[string]any `json:"states,omitempty"`
+	Traits       map[string]any `json:"traits,omitempty"`
+	MenuCategory *menuCategory  `json:"menu_category,omitempty"`
 }
 
 type menuCategory struct {
-	Category string `json:"category"`
-	Group    string `json:"group"`
+	Category           string `json:"category"`
+	Group              string `json:"group,omitempty"`
+	IsHiddenInCommands bool   `json:"is_hidden_in_commands,omitempty"`
 }
 
-func menu_category_from_map(in map[string]any) menuCategory {
-	return menuCategory{
-		Category: in["category"].(string),
-		Group:    in["group"].(string),
+func menu_category_from_map(in map[string]any) *menuCategory {
+	category, _ := in["category"].(string)
+	group, _ := in["group"].(string)
+	hidden, _ := in["is_hidden_in_commands"].(uint8)
+	return &menuCategory{
+		Category:           category,
+		Group:              group,
+		IsHiddenInCommands: hidden > 0,
 	}
 }
 
@@ -28,9 +37,11 @@ type permutation struct {
 }
 
 func permutation_from_map(in map[string]any) permutation {
+	components, _ := in["components"].(map[string]any)
+	condition, _ := in["condition"].(string)
 	return permutation{
-		Components: in["components"].(map[string]any),
-		Condition:  in["condition"].(string),
+		Components: convertComponents(components),
+		Condition:  condition,
 	}
 }
 
@@ -40,138 +51,307 @@ type MinecraftBlock struct {
 	Permutations []permutation  `json:"permutations,omitempty"`
 }
 
-func parseBlock(block protocol.BlockEntry) MinecraftBlock {
-	entry := MinecraftBlock{
-		Description: description{
-			Identifier:             block.Name,
-			IsExperimental:         true,
-			RegisterToCreativeMenu: true,
-		},
+// boolValue reads the byte nbt uses for bools
+func boolValue(v any) (bool, bool) {
+	switch v := v.(type) {
+	case uint8:
+		return v > 0, true
+	case bool:
+		return v, true
 	}
+	return false, false
+}
 
-	if perms, ok := block.Properties["permutations"].([]any); ok {
-		for _, v := range perms {
-			perm := permutation_from_map(v.(map[string]any))
-			if v, ok := perm.Components["minecraft:transformation"].(map[string]any); ok {
-				// rotation
-				rx, _ := v["RX"].(int32)
-				ry, _ := v["RY"].(int32)
-				rz, _ := v["RZ"].(int32)
-
-				// scale
-				sx, _ := v["SX"].(float32)
-				sy, _ := v["SY"].(float32)
-				sz, _ := v["SZ"].(float32)
-
-				// translation
-				tx, _ := v["TX"].(float32)
-				ty, _ := v["TY"].(float32)
-				tz, _ := v["TZ"].(float32)
-
-				perm.Components["minecraft:transformation"] = map[string][]float32{
-					"translation": {tx, ty, tz},
-					"scale":       {sx, sy, sz},
-					"rotation":    {float32(rx) * 90, float32(ry) * 90, float32(rz) * 90},
-				}
+// floatList converts a list of numbers, nbt lists of floats decode as []any
+func floatList(v any) []float32 {
+	switch v := v.(type) {
+	case []float32:
+		return v
+	case []any:
+		out := make([]float32, 0, len(v))
+		for _, f := range v {
+			switch f := f.(type) {
+			case float32:
+				out = append(out, f)
+			case float64:
+				out = append(out, float32(f))
+			case int32:
+				out = append(out, float32(f))
 			}
-			if v, ok := perm.Components["minecraft:geometry"].(map[string]any); ok {
-				if identifier, ok := v["identifier"].(string); ok {
-					perm.Components["minecraft:geometry"] = identifier
-				}
+		}
+		return out
+	}
+	return nil
+}
+
+// stateValues converts the enum of a block state, bools are sent as a byte list
+func stateValues(v any) any {
+	switch a := v.(type) {
+	case []uint8:
+		out := make([]bool, len(a))
+		for i, b := range a {
+			out[i] = b > 0
+		}
+		return out
+	case []int32, []bool, []string:
+		return a
+	case []any:
+		out := make([]any, 0, len(a))
+		for _, value := range a {
+			if b, ok := value.(uint8); ok {
+				out = append(out, b > 0)
+				continue
 			}
-			entry.Permutations = append(entry.Permutations, perm)
+			out = append(out, value)
 		}
+		return out
 	}
+	return nil
+}
 
-	if comps, ok := block.Properties["components"].(map[string]any); ok {
-		delete(comps, "minecraft:creative_category")
+func boxComponent(v map[string]any) any {
+	if enabled, ok := boolValue(v["enabled"]); ok && !enabled {
+		return false
+	}
+	return map[string]any{
+		"origin": floatList(v["origin"]),
+		"size":   floatList(v["size"]),
+	}
+}
 
-		for k, v := range comps {
-			if v, ok := v.(map[string]any); ok {
-				// fix {"value": 0.1} -> 0.1
-				if v, ok := v["value"]; ok {
-					comps[k] = v
-				}
-				// fix {"lightLevel": 15} -> 15
-				if v, ok := v["lightLevel"]; ok {
-					comps[k] = v
+func materialInstances(v map[string]any) map[string]any {
+	materials, ok := v["materials"].(map[string]any)
+	if !ok {
+		materials = v
+	}
+	out := make(map[string]any, len(materials))
+	for face, material := range materials {
+		material, ok := material.(map[string]any)
+		if !ok {
+			continue
+		}
+		m := make(map[string]any, len(material))
+		for prop, value := range material {
+			switch prop {
+			case "ambient_occlusion", "face_dimming", "isotropic":
+				if b, ok := boolValue(value); ok {
+					value = b
 				}
+			}
+			m[prop] = value
+		}
+		out[face] = m
+	}
+	// faces that use the material of another face
+	if mappings, ok := v["mappings"].(map[string]any); ok {
+		for face, instance := range mappings {
+			if _, ok := out[face]; !ok {
+				out[face] = instance
+			}
+		}
+	}
+	// fix missing * instance, always from the same face so the output doesnt change between runs
+	if _, ok := out["*"]; !ok {
+		for _, face := range []string{"up", "north", "east", "south", "west", "down", "side"} {
+			if side, ok := out[face]; ok {
+				out["*"] = side
+				break
+			}
+		}
+	}
+	return out
+}
 
-				// fix missing * instance
-				if k == "minecraft:material_instances" {
-					if m, ok := v["materials"].(map[string]any); ok {
-						comps[k] = m
-					}
-					materials := comps[k].(map[string]any)
-					for _, material := range materials {
-						material, ok := material.(map[string]any)
-						if !ok {
-							continue
-						}
-						for prop, value := range material {
-							switch prop {
-							case "ambient_occlusion", "face_dimming":
-								if value, ok := value.(uint8); ok {
-									material[prop] = value > 0
-								}
-							}
-						}
-					}
-					if _, ok := materials["*"]; !ok {
-						up, ok := materials["up"]
-						if ok {
-							materials["*"] = up
-						} else {
-							for _, side := range materials {
-								materials["*"] = side
-								break
-							}
-						}
-
-					}
-				}
-				// fix {"identifier": "name"} -> "name"
-				if v, ok := v["identifier"]; ok {
-					comps[k] = v
-				}
-				// fix {"emission": "name"} -> "name"
-				if v, ok := v["emission"]; ok {
-					comps[k] = v
+// traits converts the list of traits into the map of the description,
+// enabled_states is a compound of bytes on the network and a list of the enabled ones in the json
+func traits(v any) map[string]any {
+	list, _ := v.([]any)
+	out := make(map[string]any, len(list))
+	for _, t := range list {
+		t, ok := t.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := t["name"].(string)
+		if name == "" {
+			continue
+		}
+		trait := make(map[string]any)
+		if states, ok := t["enabled_states"].(map[string]any); ok {
+			enabled := []string{}
+			for state, on := range states {
+				if b, _ := boolValue(on); b {
+					enabled = append(enabled, state)
 				}
+			}
+			sort.Strings(enabled)
+			trait["enabled_states"] = enabled
+		}
+		for key, value := range t {
+			if key == "name" || key == "enabled_states" {
+				continue
+			}
+			trait[key] = value
+		}
+		out[name] = trait
+	}
+	return out
+}
+
+// convertComponent turns a component from the network format into the one of the block json,
+// the name can change too. ok is false for components that are left out
+func convertComponent(name string, value any) (newName string, newValue any, ok bool) {
+	v, isMap := value.(map[string]any)
+	if !isMap {
+		return name, value, true
+	}
+	if _, ok := v["triggerType"]; ok {
+		// the events of triggers are not sent by the server
+		return "", nil, false
+	}
 
-				if v, ok := v["triggerType"]; ok {
-					comps[k] = map[string]any{
-						"event": v.(string),
-					}
+	switch name {
+	case "minecraft:creative_category":
+		return "", nil, false
+	case "minecraft:unit_cube":
+		return "minecraft:geometry", "minecraft:geometry.full_block", true
+	case "minecraft:geometry":
+		identifier, _ := v["identifier"].(string)
+		geometry := map[string]any{"identifier": identifier}
+		if bones, ok := v["bone_visibility"].(map[string]any); ok && len(bones) > 0 {
+			visibility := make(map[string]any, len(bones))
+			for bone, visible := range bones {
+				if b, ok := boolValue(visible); ok {
+					visible = b
 				}
+				visibility[bone] = visible
 			}
+			geometry["bone_visibility"] = visibility
+		}
+		if culling, ok := v["culling"].(string); ok && culling != "" {
+			geometry["culling"] = culling
+		}
+		if len(geometry) == 1 {
+			return name, identifier, true
+		}
+		return name, geometry, true
+	case "minecraft:material_instances":
+		return name, materialInstances(v), true
+	case "minecraft:collision_box", "minecraft:selection_box":
+		return name, boxComponent(v), true
+	case "minecraft:block_light_emission", "minecraft:light_emission":
+		emission, ok := v["emission"].(float32)
+		if !ok {
+			return "minecraft:light_emission", v["value"], true
+		}
+		return "minecraft:light_emission", int(math.Round(float64(emission) * 15)), true
+	case "minecraft:block_light_filter", "minecraft:block_light_absorption", "minecraft:light_dampening":
+		if level, ok := v["lightLevel"]; ok {
+			return "minecraft:light_dampening", level, true
+		}
+		return "minecraft:light_dampening", v["value"], true
+	case "minecraft:destructible_by_mining":
+		if seconds, ok := v["value"]; ok {
+			return name, map[string]any{"seconds_to_destroy": seconds}, true
 		}
+	case "minecraft:destructible_by_explosion":
+		if resistance, ok := v["value"]; ok {
+			return name, map[string]any{"explosion_resistance": resistance}, true
+		}
+	case "minecraft:flammable":
+		return name, map[string]any{
+			"catch_chance_modifier":   v["flame_odds"],
+			"destroy_chance_modifier": v["burn_odds"],
+		}, true
+	case "minecraft:map_color":
+		if color, ok := v["color"]; ok {
+			return name, color, true
+		}
+	case "minecraft:transformation":
+		// rotation
+		rx, _ := v["RX"].(int32)
+		ry, _ := v["RY"].(int32)
+		rz, _ := v["RZ"].(int32)
+
+		// scale
+		sx, _ := v["SX"].(float32)
+		sy, _ := v["SY"].(float32)
+		sz, _ := v["SZ"].(float32)
+
+		// translation
+		tx, _ := v["TX"].(float32)
+		ty, _ := v["TY"].(float32)
+		tz, _ := v["TZ"].(float32)
+
+		return name, map[string][]float32{
+			"translation": {tx, ty, tz},
+			"scale":       {sx, sy, sz},
+			"rotation":    {float32(rx) * 90, float32(ry) * 90, float32(rz) * 90},
+		}, true
+	}
+
+	// fix {"value": 0.1} -> 0.1
+	if len(v) == 1 {
+		if value, ok := v["value"]; ok {
+			return name, value, true
+		}
+	}
+	return name, v, true
+}
 
-		if friction, ok := comps["minecraft:friction"].(float32); ok {
-			if friction == 0.4 {
-				delete(comps, "minecraft:friction")
+func convertComponents(comps map[string]any) map[string]any {
+	out := make(map[string]any, len(comps))
+	for name, value := range comps {
+		if name, value, ok := convertComponent(name, value); ok {
+			out[name] = value
+		}
+	}
+	if friction, ok := out["minecraft:friction"].(float32); ok {
+		if friction == 0.4 {
+			delete(out, "minecraft:friction")
+		}
+	}
+	return out
+}
+
+func parseBlock(block protocol.BlockEntry) MinecraftBlock {
+	entry := MinecraftBlock{
+		Description: description{
+			Identifier: block.Name,
+		},
+	}
+
+	if perms, ok := block.Properties["permutations"].([]any); ok {
+		for _, v := range perms {
+			if v, ok := v.(map[string]any); ok {
+				entry.Permutations = append(entry.Permutations, permutation_from_map(v))
 			}
 		}
+	}
 
-		entry.Components = comps
+	if comps, ok := block.Properties["components"].(map[string]any); ok {
+		entry.Components = convertComponents(comps)
 	}
 
 	if menu, ok := block.Properties["menu_category"].(map[string]any); ok {
 		entry.Description.MenuCategory = menu_category_from_map(menu)
 	}
 
+	// placement_direction and placement_position add states the permutations can test
+	if t := traits(block.Properties["traits"]); len(t) > 0 {
+		entry.Description.Traits = t
+	}
+
 	if props, ok := block.Properties["properties"].([]any); ok {
-		entry.Description.Properties = make(map[string]any)
+		entry.Description.States = make(map[string]any)
 		for _, v := range props {
-			v := v.(map[string]any)
-			name := v["name"].(string)
-			switch a := v["enum"].(type) {
-			case []int32:
-				entry.Description.Properties[name] = a
-			case []bool:
-				entry.Description.Properties[name] = a
-			case []any:
-				entry.Description.Properties[name] = a
+			v, ok := v.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := v["name"].(string)
+			if values := stateValues(v["enum"]); values != nil {
+				entry.Description.States[name] = values
 			}
 		}
 	}
@@ -0,0 +1,282 @@
+package behaviourpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected block json in testdata")
+
+// block entries like servers send them in StartGame, the expected json is in testdata/blocks
+var blockFixtures = []protocol.BlockEntry{
+	{
+		Name: "example:lamp",
+		Properties: map[string]any{
+			"components": map[string]any{
+				"minecraft:geometry": map[string]any{
+					"identifier": "geometry.lamp",
+					"bone_visibility": map[string]any{
+						"shade": uint8(0),
+					},
+				},
+				"minecraft:material_instances": map[string]any{
+					"mappings": map[string]any{
+						"down": "up",
+					},
+					"materials": map[string]any{
+						"up": map[string]any{
+							"texture":           "lamp_top",
+							"render_method":     "alpha_test",
+							"face_dimming":      uint8(1),
+							"ambient_occlusion": uint8(0),
+						},
+						"side": map[string]any{
+							"texture":           "lamp_side",
+							"render_method":     "opaque",
+							"face_dimming":      uint8(1),
+							"ambient_occlusion": uint8(1),
+						},
+					},
+				},
+				"minecraft:collision_box": map[string]any{
+					"enabled": uint8(1),
+					"origin":  []any{float32(-4), float32(0), float32(-4)},
+					"size":    []any{float32(8), float32(12), float32(8)},
+				},
+				"minecraft:selection_box": map[string]any{
+					"enabled": uint8(0),
+					"origin":  []any{float32(-8), float32(0), float32(-8)},
+					"size":    []any{float32(16), float32(16), float32(16)},
+				},
+				"minecraft:block_light_emission": map[string]any{"emission": float32(0.4)},
+				"minecraft:block_light_filter":   map[string]any{"lightLevel": int32(0)},
+				"minecraft:destructible_by_mining": map[string]any{
+					"value": float32(1.5),
+				},
+				"minecraft:friction": map[string]any{"value": float32(0.4)},
+				"minecraft:flammable": map[string]any{
+					"flame_odds": int32(5),
+					"burn_odds":  int32(20),
+				},
+				"minecraft:map_color":                 map[string]any{"value": "#ffcc00"},
+				"minecraft:creative_category":         map[string]any{"category": "construction"},
+				"minecraft:on_player_placing":         map[string]any{"triggerType": "placement_trigger"},
+				"minecraft:display_name":              map[string]any{"value": "tile.example:lamp.name"},
+				"minecraft:destructible_by_explosion": map[string]any{"value": float32(6)},
+			},
+			"menu_category": map[string]any{
+				"category": "items",
+				"group":    "itemGroup.name.lantern",
+			},
+			"properties": []any{
+				map[string]any{"name": "example:lit", "enum": []any{uint8(0), uint8(1)}},
+				map[string]any{"name": "example:color", "enum": []any{"white", "red"}},
+				map[string]any{"name": "example:level", "enum": []any{int32(0), int32(1), int32(2)}},
+			},
+			"permutations": []any{
+				map[string]any{
+					"condition": "q.block_state('example:lit') == true",
+					"components": map[string]any{
+						"minecraft:block_light_emission": map[string]any{"emission": float32(1)},
+					},
+				},
+				map[string]any{
+					"condition": "q.block_state('example:color') == 'red'",
+					"components": map[string]any{
+						"minecraft:material_instances": map[string]any{
+							"mappings": map[string]any{},
+							"materials": map[string]any{
+								"*": map[string]any{
+									"texture":       "lamp_red",
+									"render_method": "opaque",
+								},
+							},
+						},
+					},
+				},
+			},
+			"molangVersion": int32(10),
+			"vanilla_block_data": map[string]any{
+				"block_id": int32(10000),
+			},
+		},
+	},
+	{
+		Name: "example:rotated_slab",
+		Properties: map[string]any{
+			"components": map[string]any{
+				"minecraft:unit_cube": map[string]any{},
+				"minecraft:transformation": map[string]any{
+					"RX": int32(0), "RY": int32(1), "RZ": int32(0),
+					"SX": float32(1), "SY": float32(0.5), "SZ": float32(1),
+					"TX": float32(0), "TY": float32(-0.25), "TZ": float32(0),
+				},
+				"minecraft:material_instances": map[string]any{
+					"mappings": map[string]any{},
+					"materials": map[string]any{
+						"north": map[string]any{
+							"texture":       "slab",
+							"render_method": "opaque",
+						},
+					},
+				},
+			},
+			"menu_category": map[string]any{
+				"category":              "construction",
+				"group":                 "",
+				"is_hidden_in_commands": uint8(1),
+			},
+		},
+	},
+	{
+		Name: "example:pillar",
+		Properties: map[string]any{
+			"components": map[string]any{
+				"minecraft:unit_cube": map[string]any{},
+			},
+			"traits": []any{
+				map[string]any{
+					"name": "minecraft:placement_direction",
+					"enabled_states": map[string]any{
+						"minecraft:cardinal_direction": uint8(1),
+						"minecraft:facing_direction":   uint8(0),
+					},
+					"y_rotation_offset": float32(180),
+				},
+				map[string]any{
+					"name": "minecraft:placement_position",
+					"enabled_states": map[string]any{
+						"minecraft:block_face":    uint8(0),
+						"minecraft:vertical_half": uint8(1),
+					},
+				},
+			},
+			"permutations": []any{
+				map[string]any{
+					"condition": "q.block_state('minecraft:cardinal_direction') == 'east'",
+					"components": map[string]any{
+						"minecraft:transformation": map[string]any{
+							"RX": int32(0), "RY": int32(3), "RZ": int32(0),
+							"SX": float32(1), "SY": float32(1), "SZ": float32(1),
+							"TX": float32(0), "TY": float32(0), "TZ": float32(0),
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+// block entries captured from the StartGame of a server, stored as the network nbt of the properties.
+// crate.nbt is what dragonfly v0.9.15 sends for a block with a geometry, textures without up, a light level and
+// rotation permutations, made with blockinternal.Components like its makeBlockEntries does
+var capturedBlocks = []struct {
+	file string
+	name string
+}{
+	{file: "crate.nbt", name: "example:crate"},
+}
+
+// roundTrip encodes the entry like the network does, so the values have the types the nbt decoder gives them
+func roundTrip(t *testing.T, entry protocol.BlockEntry) protocol.BlockEntry {
+	data, err := nbt.MarshalEncoding(entry.Properties, nbt.NetworkLittleEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var properties map[string]any
+	if err := nbt.UnmarshalEncoding(data, &properties, nbt.NetworkLittleEndian); err != nil {
+		t.Fatal(err)
+	}
+	return protocol.BlockEntry{Name: entry.Name, Properties: properties}
+}
+
+func TestParseBlockFixtures(t *testing.T) {
+	for _, fixture := range blockFixtures {
+		_, name := ns_name_split(fixture.Name)
+		t.Run(name, func(t *testing.T) {
+			checkBlock(t, name, roundTrip(t, fixture))
+		})
+	}
+}
+
+func TestParseCapturedBlocks(t *testing.T) {
+	for _, captured := range capturedBlocks {
+		_, name := ns_name_split(captured.name)
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "blocks", captured.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var properties map[string]any
+			if err := nbt.UnmarshalEncoding(data, &properties, nbt.NetworkLittleEndian); err != nil {
+				t.Fatal(err)
+			}
+			checkBlock(t, name, protocol.BlockEntry{Name: captured.name, Properties: properties})
+		})
+	}
+}
+
+// checkBlock compares the json of the entry with testdata/blocks/<name>.json
+func checkBlock(t *testing.T, name string, entry protocol.BlockEntry) {
+	have, err := json.MarshalIndent(blockBehaviour{
+		FormatVersion:  "1.20.80",
+		MinecraftBlock: parseBlock(entry),
+	}, "", "\t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	have = append(have, '\n')
+
+	filename := filepath.Join("testdata", "blocks", name+".json")
+	if *update {
+		if err := os.WriteFile(filename, have, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(have, want) {
+		t.Errorf("block json differs from %s, run go test -update if the change is expected\n%s", filename, have)
+	}
+
+	// every state, trait and permutation has to make it into the json
+	var block blockBehaviour
+	if err := json.Unmarshal(have, &block); err != nil {
+		t.Fatal(err)
+	}
+	props, _ := entry.Properties["properties"].([]any)
+	if len(block.MinecraftBlock.Description.States) != len(props) {
+		t.Errorf("have %d states, want %d", len(block.MinecraftBlock.Description.States), len(props))
+	}
+	traits, _ := entry.Properties["traits"].([]any)
+	if len(block.MinecraftBlock.Description.Traits) != len(traits) {
+		t.Errorf("have %d traits, want %d", len(block.MinecraftBlock.Description.Traits), len(traits))
+	}
+	perms, _ := entry.Properties["permutations"].([]any)
+	if len(block.MinecraftBlock.Permutations) != len(perms) {
+		t.Errorf("have %d permutations, want %d", len(block.MinecraftBlock.Permutations), len(perms))
+	}
+	for _, network := range []string{"minecraft:unit_cube", "minecraft:block_light_emission", "minecraft:block_light_filter", "minecraft:creative_category"} {
+		if _, ok := block.MinecraftBlock.Components[network]; ok {
+			t.Errorf("%s was not converted", network)
+		}
+	}
+	if strings.Contains(string(have), "triggerType") {
+		t.Error("trigger components are not removed")
+	}
+	if materials, ok := block.MinecraftBlock.Components["minecraft:material_instances"].(map[string]any); ok {
+		if _, ok := materials["*"]; !ok {
+			t.Error("material_instances has no * instance")
+		}
+	}
+}
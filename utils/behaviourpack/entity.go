@@ -27,6 +27,8 @@ type EntityIn struct {
 	Identifier string
 	Attr       []protocol.AttributeValue
 	Meta       protocol.EntityMetadata
+	// dont add the damage sensor that makes the entity invulnerable
+	Vulnerable bool
 }
 
 func (bp *Pack) AddEntity(entity EntityIn) {
@@ -94,10 +96,12 @@ func (bp *Pack) AddEntity(entity EntityIn) {
 		"is_pushable":           false,
 		"is_pushable_by_piston": false,
 	}
-	entry.MinecraftEntity.Components["minecraft:damage_sensor"] = map[string]any{
-		"triggers": map[string]any{
-			"deals_damage": false,
-		},
+	if !entity.Vulnerable {
+		entry.MinecraftEntity.Components["minecraft:damage_sensor"] = map[string]any{
+			"triggers": map[string]any{
+				"deals_damage": false,
+			},
+		}
 	}
 	entry.MinecraftEntity.Components["minecraft:is_stackable"] = map[string]any{}
 	entry.MinecraftEntity.Components["minecraft:push_through"] = 1
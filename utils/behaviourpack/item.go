@@ -19,6 +19,7 @@ type itemBehaviour struct {
 }
 
 func (bp *Pack) AddItem(item protocol.ItemEntry) {
+	bp.itemNames[int32(item.RuntimeID)] = item.Name
 	ns, _ := ns_name_split(item.Name)
 	if ns == "minecraft" {
 		return
@@ -47,3 +48,40 @@ func (bp *Pack) ApplyComponentEntries(entries []protocol.ItemComponentEntry) {
 		}
 	}
 }
+
+// ItemIcon returns the name of the icon texture in item_texture.json of a custom item, empty if it has none
+func (bp *Pack) ItemIcon(name string) string {
+	item, ok := bp.items[name]
+	if !ok {
+		return ""
+	}
+	components := item.MinecraftItem.Components
+	icon, ok := components["minecraft:icon"]
+	if !ok {
+		if properties, ok := components["item_properties"].(map[string]any); ok {
+			icon = properties["minecraft:icon"]
+		}
+	}
+	switch icon := icon.(type) {
+	case string:
+		return icon
+	case map[string]any:
+		if texture, ok := icon["texture"].(string); ok {
+			return texture
+		}
+		if textures, ok := icon["textures"].(map[string]any); ok {
+			texture, _ := textures["default"].(string)
+			return texture
+		}
+	}
+	return ""
+}
+
+// ItemIcons returns the icon of every custom item by identifier, empty for items without one
+func (bp *Pack) ItemIcons() map[string]string {
+	icons := make(map[string]string, len(bp.items))
+	for name := range bp.items {
+		icons[name] = bp.ItemIcon(name)
+	}
+	return icons
+}
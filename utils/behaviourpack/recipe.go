@@ -0,0 +1,262 @@
+package behaviourpack
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// metadata of ingredients that match every variant
+const anyMetadata = 0x7fff
+
+type recipeDescription struct {
+	Identifier string `json:"identifier"`
+}
+
+type recipeItem struct {
+	Item  string `json:"item,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+	Data  *int   `json:"data,omitempty"`
+	Count int    `json:"count,omitempty"`
+}
+
+type recipeShaped struct {
+	Description recipeDescription     `json:"description"`
+	Tags        []string              `json:"tags"`
+	Pattern     []string              `json:"pattern"`
+	Key         map[string]recipeItem `json:"key"`
+	Result      []recipeItem          `json:"result"`
+	Priority    int32                 `json:"priority,omitempty"`
+}
+
+type recipeShapeless struct {
+	Description recipeDescription `json:"description"`
+	Tags        []string          `json:"tags"`
+	Ingredients []recipeItem      `json:"ingredients"`
+	Result      []recipeItem      `json:"result"`
+	Priority    int32             `json:"priority,omitempty"`
+}
+
+type recipeFurnace struct {
+	Description recipeDescription `json:"description"`
+	Tags        []string          `json:"tags"`
+	Input       recipeItem        `json:"input"`
+	Output      recipeItem        `json:"output"`
+}
+
+type recipeBehaviour struct {
+	FormatVersion string           `json:"format_version"`
+	Shaped        *recipeShaped    `json:"minecraft:recipe_shaped,omitempty"`
+	Shapeless     *recipeShapeless `json:"minecraft:recipe_shapeless,omitempty"`
+	Furnace       *recipeFurnace   `json:"minecraft:recipe_furnace,omitempty"`
+}
+
+// ItemName returns the name of an item by its network id, from the items in StartGame
+func (bp *Pack) ItemName(networkID int32) (string, bool) {
+	name, ok := bp.itemNames[networkID]
+	return name, ok
+}
+
+func isCustom(identifier string) bool {
+	ns, _ := ns_name_split(identifier)
+	return ns != "minecraft" && strings.Contains(identifier, ":")
+}
+
+func (bp *Pack) recipeDescriptor(d protocol.ItemDescriptorCount) (recipeItem, bool) {
+	var it recipeItem
+	var data int
+	switch d := d.Descriptor.(type) {
+	case *protocol.DefaultItemDescriptor:
+		name, ok := bp.ItemName(int32(d.NetworkID))
+		if !ok {
+			return it, false
+		}
+		it.Item = name
+		data = int(d.MetadataValue)
+	case *protocol.DeferredItemDescriptor:
+		it.Item = d.Name
+		data = int(d.MetadataValue)
+	case *protocol.ComplexAliasItemDescriptor:
+		it.Item = d.Name
+		data = anyMetadata
+	case *protocol.ItemTagItemDescriptor:
+		it.Tag = d.Tag
+		data = anyMetadata
+	default:
+		// molang descriptors cant be written as json
+		return it, false
+	}
+	if data != anyMetadata && data != 0 {
+		it.Data = &data
+	}
+	if d.Count > 1 {
+		it.Count = int(d.Count)
+	}
+	return it, true
+}
+
+func (bp *Pack) recipeResult(stack protocol.ItemStack) (recipeItem, bool) {
+	name, ok := bp.ItemName(stack.NetworkID)
+	if !ok {
+		return recipeItem{}, false
+	}
+	it := recipeItem{Item: name, Count: int(stack.Count)}
+	if stack.MetadataValue != 0 && stack.MetadataValue != anyMetadata {
+		data := int(stack.MetadataValue)
+		it.Data = &data
+	}
+	return it, true
+}
+
+// usesCustomItems is true when any item in the recipe is not from vanilla
+func usesCustomItems(items ...recipeItem) bool {
+	for _, it := range items {
+		if isCustom(it.Item) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddRecipe adds a crafting or furnace recipe from CraftingData.
+// vanilla recipes are already in the game so only ones with a custom id or custom items are added
+func (bp *Pack) AddRecipe(recipe protocol.Recipe) {
+	switch r := recipe.(type) {
+	case *protocol.ShapedRecipe:
+		bp.addShaped(r)
+	case *protocol.ShapelessRecipe:
+		bp.addShapeless(r)
+	case *protocol.FurnaceRecipe:
+		bp.addFurnace(r, false)
+	case *protocol.FurnaceDataRecipe:
+		bp.addFurnace(&r.FurnaceRecipe, true)
+	}
+}
+
+func (bp *Pack) addShaped(r *protocol.ShapedRecipe) {
+	var items []recipeItem
+	pattern := make([]string, r.Height)
+	key := make(map[string]recipeItem)
+	keys := make(map[string]string)
+	symbols := "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	for y := 0; y < int(r.Height); y++ {
+		row := make([]byte, r.Width)
+		for x := 0; x < int(r.Width); x++ {
+			input := r.Input[y*int(r.Width)+x]
+			if _, ok := input.Descriptor.(*protocol.InvalidItemDescriptor); ok || input.Count == 0 {
+				row[x] = ' '
+				continue
+			}
+			it, ok := bp.recipeDescriptor(input)
+			if !ok {
+				logrus.Debugf("skipping recipe %s, unknown ingredient", r.RecipeID)
+				return
+			}
+			it.Count = 0
+			k := it.Item + "#" + it.Tag
+			if it.Data != nil {
+				k += "#" + strconv.Itoa(*it.Data)
+			}
+			symbol, ok := keys[k]
+			if !ok {
+				symbol = string(symbols[len(keys)])
+				keys[k] = symbol
+				key[symbol] = it
+			}
+			row[x] = symbol[0]
+			items = append(items, it)
+		}
+		pattern[y] = string(row)
+	}
+	result, ok := bp.recipeResults(r.RecipeID, r.Output)
+	if !ok || !(isCustom(r.RecipeID) || usesCustomItems(append(items, result...)...)) {
+		return
+	}
+	bp.recipes[r.RecipeID] = &recipeBehaviour{
+		FormatVersion: "1.12",
+		Shaped: &recipeShaped{
+			Description: recipeDescription{Identifier: r.RecipeID},
+			Tags:        []string{r.Block},
+			Pattern:     pattern,
+			Key:         key,
+			Result:      result,
+			Priority:    r.Priority,
+		},
+	}
+}
+
+func (bp *Pack) addShapeless(r *protocol.ShapelessRecipe) {
+	var ingredients []recipeItem
+	for _, input := range r.Input {
+		it, ok := bp.recipeDescriptor(input)
+		if !ok {
+			logrus.Debugf("skipping recipe %s, unknown ingredient", r.RecipeID)
+			return
+		}
+		ingredients = append(ingredients, it)
+	}
+	result, ok := bp.recipeResults(r.RecipeID, r.Output)
+	if !ok || !(isCustom(r.RecipeID) || usesCustomItems(append(ingredients, result...)...)) {
+		return
+	}
+	bp.recipes[r.RecipeID] = &recipeBehaviour{
+		FormatVersion: "1.12",
+		Shapeless: &recipeShapeless{
+			Description: recipeDescription{Identifier: r.RecipeID},
+			Tags:        []string{r.Block},
+			Ingredients: ingredients,
+			Result:      result,
+			Priority:    r.Priority,
+		},
+	}
+}
+
+func (bp *Pack) recipeResults(id string, output []protocol.ItemStack) ([]recipeItem, bool) {
+	var result []recipeItem
+	for _, stack := range output {
+		it, ok := bp.recipeResult(stack)
+		if !ok {
+			logrus.Debugf("skipping recipe %s, unknown result", id)
+			return nil, false
+		}
+		result = append(result, it)
+	}
+	return result, len(result) > 0
+}
+
+func (bp *Pack) addFurnace(r *protocol.FurnaceRecipe, withData bool) {
+	inputName, ok := bp.ItemName(r.InputType.NetworkID)
+	if !ok {
+		return
+	}
+	input := recipeItem{Item: inputName}
+	if withData {
+		data := int(r.InputType.MetadataValue)
+		input.Data = &data
+	}
+	output, ok := bp.recipeResult(r.Output)
+	if !ok || !usesCustomItems(input, output) {
+		return
+	}
+	// furnace recipes have no id, so one is made from the input
+	_, name := ns_name_split(inputName)
+	id := "bedrocktool:" + r.Block + "_" + name
+	if input.Data != nil {
+		id += "_" + strconv.Itoa(*input.Data)
+	}
+	bp.recipes[id] = &recipeBehaviour{
+		FormatVersion: "1.12",
+		Furnace: &recipeFurnace{
+			Description: recipeDescription{Identifier: id},
+			Tags:        []string{r.Block},
+			Input:       input,
+			Output:      output,
+		},
+	}
+}
+
+func (bp *Pack) HasRecipes() bool {
+	return len(bp.recipes) > 0
+}
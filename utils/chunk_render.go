@@ -174,3 +174,58 @@ func Chunk2Img(c *chunk.Chunk) *image.RGBA {
 	}
 	return img
 }
+
+func shadeColor(c color.RGBA, f float64) color.RGBA {
+	scale := func(v uint8) uint8 {
+		return uint8(min(max(float64(v)*f, 0), 255))
+	}
+	return color.RGBA{scale(c.R), scale(c.G), scale(c.B), c.A}
+}
+
+// Chunk2ImgCave renders the first solid block at or below y, darker the deeper it is
+func Chunk2ImgCave(c *chunk.Chunk, y int16) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	br := c.BlockRegistry.(world.BlockRegistry)
+	minY := int16(c.Range().Min())
+	y = min(y, int16(c.Range().Max()))
+
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			for by := y; by >= minY; by-- {
+				b, found := br.BlockByRuntimeID(c.Block(x, by, z, 0))
+				if !found {
+					continue
+				}
+				if _, isAir := b.(block.Air); isAir {
+					continue
+				}
+				depth := float64(y - by)
+				img.SetRGBA(int(x), int(z), shadeColor(blockColorAt(c, x, by, z), 1-min(depth/48, 0.7)))
+				break
+			}
+		}
+	}
+	return img
+}
+
+// Chunk2ImgHeight renders the top blocks shaded by their height and the slope to the north
+func Chunk2ImgHeight(c *chunk.Chunk) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	hm := c.HeightMapWithWater()
+	r := c.Range()
+	height := float64(r.Max() - r.Min())
+
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			h := hm.At(x, z)
+			north := h
+			if z > 0 {
+				north = hm.At(x, z-1)
+			}
+			slope := float64(min(max(h-north, -4), 4)) * 0.08
+			level := float64(int(h)-r.Min())/height*0.5 + 0.75
+			img.SetRGBA(int(x), int(z), shadeColor(chunkGetColorAt(c, x, h, z), level+slope))
+		}
+	}
+	return img
+}
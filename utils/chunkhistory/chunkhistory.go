@@ -0,0 +1,257 @@
+// Package chunkhistory keeps every distinct version of the chunks received in a session,
+// stored in a folder next to the world as an append only data file and a jsonl index
+package chunkhistory
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/OneOfOne/xxhash"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+)
+
+// Suffix is added to the world folder for the history folder
+const Suffix = ".history"
+
+const (
+	dataFile  = "chunks.bin"
+	indexFile = "index.jsonl"
+)
+
+// Version is one stored version of a chunk
+type Version struct {
+	X         int32 `json:"x"`
+	Z         int32 `json:"z"`
+	Dimension int   `json:"dimension"`
+	// unix milliseconds
+	Time   int64 `json:"time"`
+	Offset int64 `json:"offset"`
+	Size   int64 `json:"size"`
+	Min    int   `json:"min"`
+	Max    int   `json:"max"`
+}
+
+func (v Version) Pos() world.ChunkPos {
+	return world.ChunkPos{v.X, v.Z}
+}
+
+type versionKey struct {
+	dim int
+	pos world.ChunkPos
+}
+
+// Writer appends chunk versions, nothing is created until the first chunk
+type Writer struct {
+	Folder string
+
+	l      sync.Mutex
+	data   *os.File
+	index  *os.File
+	offset int64
+	last   map[versionKey]uint64
+}
+
+func NewWriter(folder string) *Writer {
+	return &Writer{
+		Folder: folder,
+		last:   make(map[versionKey]uint64),
+	}
+}
+
+func (w *Writer) open() (err error) {
+	if w.data != nil {
+		return nil
+	}
+	if err = os.MkdirAll(w.Folder, 0o777); err != nil {
+		return err
+	}
+	w.data, err = os.OpenFile(filepath.Join(w.Folder, dataFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	stat, err := w.data.Stat()
+	if err != nil {
+		return err
+	}
+	w.offset = stat.Size()
+	w.index, err = os.OpenFile(filepath.Join(w.Folder, indexFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	return err
+}
+
+// encode writes the disk encoding of the chunk as length prefixed sub chunks followed by the biomes
+func encode(ch *chunk.Chunk) []byte {
+	data := chunk.Encode(ch, chunk.DiskEncoding)
+	buf := bytes.NewBuffer(nil)
+	binary.Write(buf, binary.LittleEndian, uint32(len(data.SubChunks)))
+	for _, sub := range data.SubChunks {
+		binary.Write(buf, binary.LittleEndian, uint32(len(sub)))
+		buf.Write(sub)
+	}
+	binary.Write(buf, binary.LittleEndian, uint32(len(data.Biomes)))
+	buf.Write(data.Biomes)
+	return buf.Bytes()
+}
+
+// Add stores the chunk if it is different from the last stored version
+func (w *Writer) Add(dim world.Dimension, pos world.ChunkPos, ch *chunk.Chunk, t time.Time) error {
+	raw := encode(ch)
+	hash := xxhash.Checksum64(raw)
+	dimID, _ := world.DimensionID(dim)
+	k := versionKey{dimID, pos}
+
+	w.l.Lock()
+	defer w.l.Unlock()
+	if last, ok := w.last[k]; ok && last == hash {
+		return nil
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(raw)
+	zw.Close()
+	if _, err := w.data.Write(compressed.Bytes()); err != nil {
+		return err
+	}
+
+	r := ch.Range()
+	line, _ := json.Marshal(Version{
+		X:         pos[0],
+		Z:         pos[1],
+		Dimension: dimID,
+		Time:      t.UnixMilli(),
+		Offset:    w.offset,
+		Size:      int64(compressed.Len()),
+		Min:       r.Min(),
+		Max:       r.Max(),
+	})
+	w.offset += int64(compressed.Len())
+	if _, err := w.index.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	w.last[k] = hash
+	return nil
+}
+
+func (w *Writer) closeFiles() error {
+	if w.data == nil {
+		return nil
+	}
+	err := errors.Join(w.data.Close(), w.index.Close())
+	w.data, w.index = nil, nil
+	return err
+}
+
+// Rename moves the history folder, used when the world is renamed
+func (w *Writer) Rename(folder string) error {
+	w.l.Lock()
+	defer w.l.Unlock()
+	if err := w.closeFiles(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(w.Folder); err == nil {
+		os.RemoveAll(folder)
+		if err := os.Rename(w.Folder, folder); err != nil {
+			return err
+		}
+	}
+	w.Folder = folder
+	return nil
+}
+
+func (w *Writer) Close() error {
+	w.l.Lock()
+	defer w.l.Unlock()
+	return w.closeFiles()
+}
+
+// Reader reads the versions of a history folder
+type Reader struct {
+	Versions []Version
+	data     *os.File
+}
+
+// Open reads the index, the versions are in the order they were received
+func Open(folder string) (*Reader, error) {
+	indexData, err := os.ReadFile(filepath.Join(folder, indexFile))
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{}
+	dec := json.NewDecoder(bytes.NewReader(indexData))
+	for {
+		var v Version
+		if err := dec.Decode(&v); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		r.Versions = append(r.Versions, v)
+	}
+	r.data, err = os.Open(filepath.Join(folder, dataFile))
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Load decodes a version of a chunk
+func (r *Reader) Load(v Version, br world.BlockRegistry) (*chunk.Chunk, error) {
+	compressed := make([]byte, v.Size)
+	if _, err := r.data.ReadAt(compressed, v.Offset); err != nil {
+		return nil, err
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(raw)
+	readBytes := func() ([]byte, error) {
+		var n uint32
+		if err := binary.Read(buf, binary.LittleEndian, &n); err != nil {
+			return nil, err
+		}
+		if int(n) > buf.Len() {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return buf.Next(int(n)), nil
+	}
+	var count uint32
+	if err := binary.Read(buf, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+	var data chunk.SerialisedData
+	for i := uint32(0); i < count; i++ {
+		sub, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		data.SubChunks = append(data.SubChunks, sub)
+	}
+	if data.Biomes, err = readBytes(); err != nil {
+		return nil, err
+	}
+	return chunk.DiskDecode(br, data, cube.Range{v.Min, v.Max})
+}
+
+func (r *Reader) Close() error {
+	return r.data.Close()
+}
@@ -168,6 +168,14 @@ func ParseServer(ctx context.Context, server string) (*messages.ServerInput, err
 	}, nil
 }
 
+// RealmID returns the id of a realm:name:id server input, empty for other servers
+func RealmID(server string) string {
+	if !realmRegex.MatchString(server) {
+		return ""
+	}
+	return regexGetParams(realmRegex, server)["ID"]
+}
+
 func ValidateServerInput(server string) bool {
 	if pcapRegex.MatchString(server) {
 		return true
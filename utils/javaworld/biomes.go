@@ -0,0 +1,69 @@
+package javaworld
+
+import "strings"
+
+// bedrock biome names that are different on java
+var renamedBiomes = map[string]string{
+	"hell":                             "nether_wastes",
+	"extreme_hills":                    "windswept_hills",
+	"extreme_hills_plus_trees":         "windswept_forest",
+	"extreme_hills_mutated":            "windswept_gravelly_hills",
+	"extreme_hills_plus_trees_mutated": "windswept_gravelly_hills",
+	"extreme_hills_edge":               "windswept_hills",
+	"roofed_forest":                    "dark_forest",
+	"roofed_forest_mutated":            "dark_forest",
+	"ice_plains":                       "snowy_plains",
+	"ice_plains_spikes":                "ice_spikes",
+	"ice_mountains":                    "snowy_plains",
+	"cold_taiga":                       "snowy_taiga",
+	"cold_taiga_hills":                 "snowy_taiga",
+	"cold_taiga_mutated":               "snowy_taiga",
+	"cold_beach":                       "snowy_beach",
+	"mega_taiga":                       "old_growth_pine_taiga",
+	"mega_taiga_hills":                 "old_growth_pine_taiga",
+	"redwood_taiga_mutated":            "old_growth_spruce_taiga",
+	"redwood_taiga_hills_mutated":      "old_growth_spruce_taiga",
+	"taiga_hills":                      "taiga",
+	"taiga_mutated":                    "taiga",
+	"mesa":                             "badlands",
+	"mesa_bryce":                       "eroded_badlands",
+	"mesa_plateau":                     "badlands",
+	"mesa_plateau_stone":               "wooded_badlands",
+	"mesa_plateau_mutated":             "badlands",
+	"mesa_plateau_stone_mutated":       "wooded_badlands",
+	"savanna_mutated":                  "windswept_savanna",
+	"savanna_plateau_mutated":          "windswept_savanna",
+	"birch_forest_mutated":             "old_growth_birch_forest",
+	"birch_forest_hills_mutated":       "old_growth_birch_forest",
+	"birch_forest_hills":               "birch_forest",
+	"forest_hills":                     "forest",
+	"jungle_edge":                      "sparse_jungle",
+	"jungle_edge_mutated":              "sparse_jungle",
+	"jungle_hills":                     "jungle",
+	"jungle_mutated":                   "jungle",
+	"bamboo_jungle_hills":              "bamboo_jungle",
+	"swampland":                        "swamp",
+	"swampland_mutated":                "swamp",
+	"mushroom_island":                  "mushroom_fields",
+	"mushroom_island_shore":            "mushroom_fields",
+	"stone_beach":                      "stony_shore",
+	"desert_hills":                     "desert",
+	"desert_mutated":                   "desert",
+	"legacy_frozen_ocean":              "frozen_ocean",
+	"deep_warm_ocean":                  "warm_ocean",
+	"soulsand_valley":                  "soul_sand_valley",
+}
+
+// javaBiome returns the java name of a bedrock biome, custom biomes become plains
+func javaBiome(name string) string {
+	namespace, base, found := strings.Cut(name, ":")
+	if !found {
+		base = namespace
+	} else if namespace != "minecraft" {
+		return "minecraft:plains"
+	}
+	if n, ok := renamedBiomes[base]; ok {
+		base = n
+	}
+	return "minecraft:" + base
+}
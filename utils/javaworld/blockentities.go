@@ -0,0 +1,139 @@
+package javaworld
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// bedrock block entity ids to java
+var blockEntityIDs = map[string]string{
+	"Chest":                 "chest",
+	"Barrel":                "barrel",
+	"ShulkerBox":            "shulker_box",
+	"Hopper":                "hopper",
+	"Dispenser":             "dispenser",
+	"Dropper":               "dropper",
+	"Furnace":               "furnace",
+	"BlastFurnace":          "blast_furnace",
+	"Smoker":                "smoker",
+	"BrewingStand":          "brewing_stand",
+	"Sign":                  "sign",
+	"HangingSign":           "hanging_sign",
+	"Bed":                   "bed",
+	"Banner":                "banner",
+	"Skull":                 "skull",
+	"MobSpawner":            "mob_spawner",
+	"EnderChest":            "ender_chest",
+	"Beacon":                "beacon",
+	"Bell":                  "bell",
+	"Lectern":               "lectern",
+	"Jukebox":               "jukebox",
+	"EnchantTable":          "enchanting_table",
+	"Campfire":              "campfire",
+	"Beehive":               "beehive",
+	"Comparator":            "comparator",
+	"DaylightDetector":      "daylight_detector",
+	"EndGateway":            "end_gateway",
+	"EndPortal":             "end_portal",
+	"Conduit":               "conduit",
+	"StructureBlock":        "structure_block",
+	"CommandBlock":          "command_block",
+	"DecoratedPot":          "decorated_pot",
+	"ChiseledBookshelf":     "chiseled_bookshelf",
+	"BrushableBlock":        "brushable_block",
+	"SculkSensor":           "sculk_sensor",
+	"CalibratedSculkSensor": "calibrated_sculk_sensor",
+	"SculkCatalyst":         "sculk_catalyst",
+	"SculkShrieker":         "sculk_shrieker",
+}
+
+// javaItem converts a bedrock item stack, nil for empty slots
+func javaItem(item map[string]any) map[string]any {
+	name, _ := item["Name"].(string)
+	count, _ := item["Count"].(uint8)
+	if name == "" || name == "minecraft:air" || count == 0 {
+		return nil
+	}
+	out := map[string]any{
+		"id":    name,
+		"Count": count,
+	}
+	if slot, ok := item["Slot"].(uint8); ok {
+		out["Slot"] = slot
+	}
+	return out
+}
+
+func javaItems(items []any) []map[string]any {
+	out := []map[string]any{}
+	for _, it := range items {
+		m, ok := it.(map[string]any)
+		if !ok {
+			continue
+		}
+		if j := javaItem(m); j != nil {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
+// signText converts a bedrock sign side to the java messages
+func signText(side map[string]any) map[string]any {
+	text, _ := side["Text"].(string)
+	lines := strings.Split(text, "\n")
+	messages := make([]any, 4)
+	for i := range messages {
+		line := ""
+		if i < len(lines) {
+			line = lines[i]
+		}
+		b, _ := json.Marshal(map[string]any{"text": line})
+		messages[i] = string(b)
+	}
+	glowing, _ := side["IgnoreLighting"].(uint8)
+	return map[string]any{
+		"messages":         messages,
+		"color":            "black",
+		"has_glowing_text": glowing,
+	}
+}
+
+// javaBlockEntity converts a bedrock block entity, nil if java doesnt have it
+func javaBlockEntity(m map[string]any) map[string]any {
+	id, _ := m["id"].(string)
+	javaID, ok := blockEntityIDs[id]
+	if !ok {
+		return nil
+	}
+	out := map[string]any{
+		"id":         "minecraft:" + javaID,
+		"x":          m["x"],
+		"y":          m["y"],
+		"z":          m["z"],
+		"keepPacked": uint8(0),
+	}
+	if name, ok := m["CustomName"].(string); ok && name != "" {
+		b, _ := json.Marshal(map[string]any{"text": name})
+		out["CustomName"] = string(b)
+	}
+
+	switch id {
+	case "Sign", "HangingSign":
+		if front, ok := m["FrontText"].(map[string]any); ok {
+			out["front_text"] = signText(front)
+		} else {
+			out["front_text"] = signText(m)
+		}
+		if back, ok := m["BackText"].(map[string]any); ok {
+			out["back_text"] = signText(back)
+		}
+		waxed, _ := m["IsWaxed"].(uint8)
+		out["is_waxed"] = waxed
+	default:
+		if items, ok := m["Items"].([]any); ok {
+			out["Items"] = javaItems(items)
+		}
+	}
+	return out
+}
@@ -0,0 +1,363 @@
+package javaworld
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Block is a java block state
+type Block struct {
+	Name       string
+	Properties map[string]string
+}
+
+func (b Block) NBT() map[string]any {
+	m := map[string]any{"Name": b.Name}
+	if len(b.Properties) > 0 {
+		props := make(map[string]any, len(b.Properties))
+		for k, v := range b.Properties {
+			props[k] = v
+		}
+		m["Properties"] = props
+	}
+	return m
+}
+
+// waterlogged returns a copy of the block with waterlogged set
+func (b Block) waterlogged() Block {
+	props := make(map[string]string, len(b.Properties)+1)
+	for k, v := range b.Properties {
+		props[k] = v
+	}
+	props["waterlogged"] = "true"
+	return Block{Name: b.Name, Properties: props}
+}
+
+// isWater reports if a bedrock block in the second layer makes the block waterlogged on java
+func isWater(name string) bool {
+	return name == "minecraft:water" || name == "minecraft:flowing_water"
+}
+
+// key is used to dedupe palette entries
+func (b Block) key() string {
+	keys := make([]string, 0, len(b.Properties))
+	for k, v := range b.Properties {
+		keys = append(keys, k+"="+v)
+	}
+	sort.Strings(keys)
+	return b.Name + "[" + strings.Join(keys, ",") + "]"
+}
+
+// ParseBlock parses minecraft:name[key=value,...]
+func ParseBlock(s string) Block {
+	b := Block{Name: s}
+	if i := strings.IndexByte(s, '['); i != -1 && strings.HasSuffix(s, "]") {
+		b.Name = s[:i]
+		b.Properties = make(map[string]string)
+		for _, kv := range strings.Split(s[i+1:len(s)-1], ",") {
+			k, v, ok := strings.Cut(kv, "=")
+			if ok {
+				b.Properties[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+	}
+	if !strings.Contains(b.Name, ":") {
+		b.Name = "minecraft:" + b.Name
+	}
+	return b
+}
+
+// FallbackTable maps bedrock block names to java blocks for blocks that cant be translated,
+// the key * is used for all custom blocks that are not in the table
+type FallbackTable map[string]string
+
+const defaultFallback = "minecraft:stone"
+
+func ReadFallbackTable(filename string) (FallbackTable, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var t FallbackTable
+	err = json.Unmarshal(data, &t)
+	return t, err
+}
+
+// bedrock names that are different on java
+var renamedBlocks = map[string]string{
+	"grass":                       "grass_block",
+	"snow_layer":                  "snow",
+	"snow":                        "snow_block",
+	"lit_pumpkin":                 "jack_o_lantern",
+	"waterlily":                   "lily_pad",
+	"web":                         "cobweb",
+	"yellow_flower":               "dandelion",
+	"deadbush":                    "dead_bush",
+	"brick_block":                 "bricks",
+	"hardened_clay":               "terracotta",
+	"stonebrick":                  "stone_bricks",
+	"quartz_ore":                  "nether_quartz_ore",
+	"nether_brick":                "nether_bricks",
+	"red_nether_brick":            "red_nether_bricks",
+	"end_bricks":                  "end_stone_bricks",
+	"end_stone_brick":             "end_stone_bricks",
+	"mob_spawner":                 "spawner",
+	"reeds":                       "sugar_cane",
+	"flowing_water":               "water",
+	"flowing_lava":                "lava",
+	"invisible_bedrock":           "barrier",
+	"slime":                       "slime_block",
+	"melon_block":                 "melon",
+	"golden_rail":                 "powered_rail",
+	"noteblock":                   "note_block",
+	"trapdoor":                    "oak_trapdoor",
+	"wooden_door":                 "oak_door",
+	"fence_gate":                  "oak_fence_gate",
+	"wooden_pressure_plate":       "oak_pressure_plate",
+	"wooden_button":               "oak_button",
+	"standing_sign":               "oak_sign",
+	"wall_sign":                   "oak_wall_sign",
+	"magma":                       "magma_block",
+	"portal":                      "nether_portal",
+	"stone_stairs":                "cobblestone_stairs",
+	"normal_stone_stairs":         "stone_stairs",
+	"carpet":                      "white_carpet",
+	"concrete":                    "white_concrete",
+	"concrete_powder":             "white_concrete_powder",
+	"wool":                        "white_wool",
+	"stained_hardened_clay":       "white_terracotta",
+	"stained_glass":               "white_stained_glass",
+	"stained_glass_pane":          "white_stained_glass_pane",
+	"undyed_shulker_box":          "shulker_box",
+	"unpowered_repeater":          "repeater",
+	"powered_repeater":            "repeater",
+	"unpowered_comparator":        "comparator",
+	"powered_comparator":          "comparator",
+	"lit_redstone_lamp":           "redstone_lamp",
+	"lit_furnace":                 "furnace",
+	"lit_blast_furnace":           "blast_furnace",
+	"lit_smoker":                  "smoker",
+	"lit_redstone_ore":            "redstone_ore",
+	"lit_deepslate_redstone_ore":  "deepslate_redstone_ore",
+	"unlit_redstone_torch":        "redstone_torch",
+	"frame":                       "air",
+	"glow_frame":                  "air",
+	"item_frame":                  "air",
+	"element_0":                   "air",
+	"camera":                      "air",
+	"border_block":                "barrier",
+	"allow":                       "barrier",
+	"deny":                        "barrier",
+	"wooden_slab":                 "slab",
+	"tallgrass":                   "short_grass",
+	"double_plant":                "tall_grass",
+	"red_flower":                  "poppy",
+	"monster_egg":                 "infested_stone",
+	"darkoak_standing_sign":       "dark_oak_sign",
+	"darkoak_wall_sign":           "dark_oak_wall_sign",
+	"silver_glazed_terracotta":    "light_gray_glazed_terracotta",
+	"moving_block":                "moving_piston",
+	"piston_arm_collision":        "piston_head",
+	"sticky_piston_arm_collision": "piston_head",
+	"chemistry_table":             "air",
+	"light_block":                 "light",
+}
+
+// blocks that were one block with a type state in older versions
+var typeStates = map[string]func(v string) string{
+	"color": func(v string) string {
+		if v == "silver" {
+			v = "light_gray"
+		}
+		return v
+	},
+	"wood_type":     func(v string) string { return v },
+	"old_log_type":  func(v string) string { return v },
+	"new_log_type":  func(v string) string { return v },
+	"old_leaf_type": func(v string) string { return v },
+	"new_leaf_type": func(v string) string { return v },
+}
+
+var stoneTypes = map[string]string{
+	"stone":           "stone",
+	"granite":         "granite",
+	"granite_smooth":  "polished_granite",
+	"diorite":         "diorite",
+	"diorite_smooth":  "polished_diorite",
+	"andesite":        "andesite",
+	"andesite_smooth": "polished_andesite",
+}
+
+var facingDirections = []string{"down", "up", "north", "south", "west", "east"}
+var directions = []string{"south", "west", "north", "east"}
+var stairDirections = []string{"east", "west", "south", "north"}
+
+func stateString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case uint8:
+		return strconv.FormatBool(v != 0)
+	case int32:
+		return strconv.Itoa(int(v))
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func stateInt(v any) int {
+	switch v := v.(type) {
+	case int32:
+		return int(v)
+	case uint8:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+func stateBool(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case uint8:
+		return v != 0
+	case int32:
+		return v != 0
+	default:
+		return false
+	}
+}
+
+func half(top bool, topName, bottomName string) string {
+	if top {
+		return topName
+	}
+	return bottomName
+}
+
+// Translator converts bedrock block states to java
+type Translator struct {
+	Fallback FallbackTable
+	// bedrock names that were replaced with a fallback
+	Unmapped map[string]string
+}
+
+func NewTranslator(fallback FallbackTable) *Translator {
+	if fallback == nil {
+		fallback = FallbackTable{}
+	}
+	return &Translator{
+		Fallback: fallback,
+		Unmapped: make(map[string]string),
+	}
+}
+
+// Translate converts one bedrock block, unknown properties are ignored by java when loading
+func (t *Translator) Translate(name string, states map[string]any) Block {
+	if s, ok := t.Fallback[name]; ok {
+		return ParseBlock(s)
+	}
+
+	namespace, base, _ := strings.Cut(name, ":")
+	if namespace != "minecraft" {
+		s, ok := t.Fallback["*"]
+		if !ok {
+			s = defaultFallback
+		}
+		t.Unmapped[name] = s
+		return ParseBlock(s)
+	}
+
+	b := Block{Properties: make(map[string]string)}
+	if n, ok := renamedBlocks[base]; ok {
+		base = n
+	}
+	if strings.HasPrefix(name, "minecraft:lit_") {
+		b.Properties["lit"] = "true"
+	} else if strings.HasPrefix(name, "minecraft:powered_") {
+		b.Properties["powered"] = "true"
+	}
+
+	// sorted so the result doesnt depend on the map order
+	keys := make([]string, 0, len(states))
+	for state := range states {
+		keys = append(keys, state)
+	}
+	sort.Strings(keys)
+
+	for _, state := range keys {
+		value := states[state]
+		v := stateString(value)
+		if f, ok := typeStates[state]; ok {
+			// the rename may already include a default color
+			base = f(v) + "_" + strings.TrimPrefix(base, "white_")
+			continue
+		}
+
+		switch state {
+		case "stone_type":
+			if n, ok := stoneTypes[v]; ok {
+				base = n
+			}
+		case "liquid_depth":
+			b.Properties["level"] = v
+		case "pillar_axis":
+			b.Properties["axis"] = v
+		case "facing_direction":
+			if i := stateInt(value); i >= 0 && i < len(facingDirections) {
+				b.Properties["facing"] = facingDirections[i]
+			}
+		case "minecraft:cardinal_direction", "minecraft:facing_direction", "minecraft:block_face":
+			b.Properties["facing"] = v
+		case "direction":
+			if i := stateInt(value); i >= 0 && i < len(directions) {
+				b.Properties["facing"] = directions[i]
+			}
+		case "weirdo_direction":
+			if i := stateInt(value); i >= 0 && i < len(stairDirections) {
+				b.Properties["facing"] = stairDirections[i]
+			}
+		case "upside_down_bit":
+			b.Properties["half"] = half(stateBool(value), "top", "bottom")
+		case "top_slot_bit":
+			b.Properties["type"] = half(stateBool(value), "top", "bottom")
+		case "minecraft:vertical_half":
+			b.Properties["type"] = v
+		case "upper_block_bit":
+			b.Properties["half"] = half(stateBool(value), "upper", "lower")
+		case "door_hinge_bit":
+			b.Properties["hinge"] = half(stateBool(value), "right", "left")
+		case "open_bit":
+			b.Properties["open"] = strconv.FormatBool(stateBool(value))
+		case "powered_bit", "button_pressed_bit":
+			b.Properties["powered"] = strconv.FormatBool(stateBool(value))
+		case "in_wall_bit":
+			b.Properties["in_wall"] = strconv.FormatBool(stateBool(value))
+		case "persistent_bit":
+			b.Properties["persistent"] = strconv.FormatBool(stateBool(value))
+		case "growth":
+			b.Properties["age"] = v
+		case "redstone_signal":
+			b.Properties["power"] = v
+		case "ground_sign_direction":
+			b.Properties["rotation"] = v
+		case "height":
+			if base == "snow" {
+				b.Properties["layers"] = strconv.Itoa(stateInt(value) + 1)
+			}
+		default:
+			b.Properties[strings.TrimSuffix(state, "_bit")] = v
+		}
+	}
+	b.Name = "minecraft:" + base
+	return b
+}
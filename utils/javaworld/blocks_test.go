@@ -0,0 +1,128 @@
+package javaworld
+
+import "testing"
+
+func TestTranslate(t *testing.T) {
+	type test struct {
+		name     string
+		states   map[string]any
+		fallback FallbackTable
+		expected string
+		unmapped string
+	}
+
+	var tests = []test{
+		{
+			name:     "minecraft:red_wool",
+			expected: "minecraft:red_wool",
+		},
+		{
+			name:     "minecraft:wool",
+			states:   map[string]any{"color": "silver"},
+			expected: "minecraft:light_gray_wool",
+		},
+		{
+			name:     "minecraft:stained_glass_pane",
+			states:   map[string]any{"color": "blue"},
+			expected: "minecraft:blue_stained_glass_pane",
+		},
+		{
+			name:     "minecraft:wooden_slab",
+			states:   map[string]any{"wood_type": "spruce", "top_slot_bit": uint8(1)},
+			expected: "minecraft:spruce_slab[type=top]",
+		},
+		{
+			name:     "minecraft:oak_slab",
+			states:   map[string]any{"minecraft:vertical_half": "bottom"},
+			expected: "minecraft:oak_slab[type=bottom]",
+		},
+		{
+			name:     "minecraft:oak_stairs",
+			states:   map[string]any{"weirdo_direction": int32(2), "upside_down_bit": uint8(1)},
+			expected: "minecraft:oak_stairs[facing=south,half=top]",
+		},
+		{
+			name:     "minecraft:stone_stairs",
+			states:   map[string]any{"weirdo_direction": int32(0), "upside_down_bit": uint8(0)},
+			expected: "minecraft:cobblestone_stairs[facing=east,half=bottom]",
+		},
+		{
+			name:     "minecraft:snow_layer",
+			states:   map[string]any{"height": int32(0), "covered_bit": uint8(0)},
+			expected: "minecraft:snow[covered=false,layers=1]",
+		},
+		{
+			name:     "minecraft:snow_layer",
+			states:   map[string]any{"height": int32(7), "covered_bit": uint8(0)},
+			expected: "minecraft:snow[covered=false,layers=8]",
+		},
+		{
+			name:     "minecraft:snow",
+			expected: "minecraft:snow_block",
+		},
+		{
+			name:     "minecraft:stone",
+			states:   map[string]any{"stone_type": "diorite_smooth"},
+			expected: "minecraft:polished_diorite",
+		},
+		{
+			name:     "minecraft:flowing_water",
+			states:   map[string]any{"liquid_depth": int32(3)},
+			expected: "minecraft:water[level=3]",
+		},
+		{
+			name:     "minecraft:lit_furnace",
+			states:   map[string]any{"minecraft:cardinal_direction": "north"},
+			expected: "minecraft:furnace[facing=north,lit=true]",
+		},
+		{
+			name:     "example:crate",
+			expected: "minecraft:stone",
+			unmapped: "minecraft:stone",
+		},
+		{
+			name:     "example:crate",
+			fallback: FallbackTable{"*": "minecraft:barrel[facing=up]"},
+			expected: "minecraft:barrel[facing=up]",
+			unmapped: "minecraft:barrel[facing=up]",
+		},
+		{
+			name:     "example:crate",
+			fallback: FallbackTable{"*": "minecraft:barrel", "example:crate": "minecraft:chest"},
+			expected: "minecraft:chest",
+		},
+	}
+
+	for _, tc := range tests {
+		tr := NewTranslator(tc.fallback)
+		b := tr.Translate(tc.name, tc.states)
+		if got := b.key(); got != ParseBlock(tc.expected).key() {
+			t.Errorf("%s %v: got %s, expected %s", tc.name, tc.states, got, tc.expected)
+		}
+		if got := tr.Unmapped[tc.name]; got != tc.unmapped {
+			t.Errorf("%s: unmapped %q, expected %q", tc.name, got, tc.unmapped)
+		}
+	}
+}
+
+func TestWaterlogged(t *testing.T) {
+	slab := NewTranslator(nil).Translate("minecraft:oak_slab", map[string]any{"minecraft:vertical_half": "bottom"})
+	var tests = map[string]string{
+		"minecraft:water":         "minecraft:oak_slab[type=bottom,waterlogged=true]",
+		"minecraft:flowing_water": "minecraft:oak_slab[type=bottom,waterlogged=true]",
+		"minecraft:air":           "minecraft:oak_slab[type=bottom]",
+		"minecraft:lava":          "minecraft:oak_slab[type=bottom]",
+	}
+	for layer1, expected := range tests {
+		b := slab
+		if isWater(layer1) {
+			b = b.waterlogged()
+		}
+		if b.key() != ParseBlock(expected).key() {
+			t.Errorf("layer 1 %s: got %s, expected %s", layer1, b.key(), expected)
+		}
+	}
+	if _, ok := slab.Properties["waterlogged"]; ok {
+		t.Error("waterlogged changed the original block")
+	}
+}
@@ -0,0 +1,217 @@
+// Package javaworld converts worlds saved by bedrocktool to java edition anvil worlds
+package javaworld
+
+import (
+	"math/bits"
+	"path/filepath"
+	"reflect"
+
+	"github.com/bedrock-tool/bedrocktool/utils/mcworld"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/sirupsen/logrus"
+)
+
+// 1.20.4
+const (
+	DataVersion = 3700
+	VersionName = "1.20.4"
+)
+
+// folders of the dimensions in a java world
+var dimensionFolders = map[world.Dimension]string{
+	world.Overworld: "region",
+	world.Nether:    filepath.Join("DIM-1", "region"),
+	world.End:       filepath.Join("DIM1", "region"),
+}
+
+// longArray makes a value that is encoded as TAG_Long_Array, slices are written as lists
+func longArray(v []int64) any {
+	arr := reflect.New(reflect.ArrayOf(len(v), reflect.TypeOf(int64(0)))).Elem()
+	reflect.Copy(arr, reflect.ValueOf(v))
+	return arr.Interface()
+}
+
+// packIndices packs palette indices into longs the way java does, entries dont span across longs
+func packIndices(indices []int, paletteSize int, minBits int) any {
+	if paletteSize <= 1 {
+		return nil
+	}
+	b := max(bits.Len(uint(paletteSize-1)), minBits)
+	perLong := 64 / b
+	data := make([]int64, (len(indices)+perLong-1)/perLong)
+	for i, idx := range indices {
+		data[i/perLong] |= int64(idx) << ((i % perLong) * b)
+	}
+	return longArray(data)
+}
+
+type palette[T any] struct {
+	entries []T
+	index   map[string]int
+}
+
+func newPalette[T any]() *palette[T] {
+	return &palette[T]{index: make(map[string]int)}
+}
+
+func (p *palette[T]) add(key string, v T) int {
+	i, ok := p.index[key]
+	if !ok {
+		i = len(p.entries)
+		p.entries = append(p.entries, v)
+		p.index[key] = i
+	}
+	return i
+}
+
+type converter struct {
+	w          *mcworld.World
+	translator *Translator
+	// translated blocks by runtime id
+	blocks map[uint32]Block
+}
+
+func (c *converter) block(ch *chunk.Chunk, x uint8, y int16, z uint8) Block {
+	rid := ch.Block(x, y, z, 0)
+	b, ok := c.blocks[rid]
+	if !ok {
+		bl, found := c.w.Blocks.BlockByRuntimeID(rid)
+		if found {
+			name, properties := bl.EncodeBlock()
+			b = c.translator.Translate(name, properties)
+		} else {
+			b = Block{Name: "minecraft:air"}
+		}
+		c.blocks[rid] = b
+	}
+
+	// water in the second layer is waterlogging on java
+	if rid1 := ch.Block(x, y, z, 1); rid1 != rid {
+		bl, found := c.w.Blocks.BlockByRuntimeID(rid1)
+		if found {
+			if name, _ := bl.EncodeBlock(); isWater(name) {
+				b = b.waterlogged()
+			}
+		}
+	}
+	return b
+}
+
+func (c *converter) biome(id uint32) string {
+	b, ok := c.w.Biomes.BiomeByID(int(id))
+	if !ok {
+		return "minecraft:plains"
+	}
+	return javaBiome(b.String())
+}
+
+// chunkNBT converts a chunk to the java format
+func (c *converter) chunkNBT(pos world.ChunkPos, ch *chunk.Chunk) (map[string]any, error) {
+	r := ch.Range()
+	var sections []map[string]any
+	for sy := r.Min() >> 4; sy <= r.Max()>>4; sy++ {
+		blocks := newPalette[Block]()
+		blockIndices := make([]int, 4096)
+		for y := 0; y < 16; y++ {
+			for z := 0; z < 16; z++ {
+				for x := 0; x < 16; x++ {
+					b := c.block(ch, uint8(x), int16(sy<<4+y), uint8(z))
+					blockIndices[y*256+z*16+x] = blocks.add(b.key(), b)
+				}
+			}
+		}
+
+		biomes := newPalette[string]()
+		biomeIndices := make([]int, 64)
+		for y := 0; y < 4; y++ {
+			for z := 0; z < 4; z++ {
+				for x := 0; x < 4; x++ {
+					b := c.biome(ch.Biome(uint8(x*4), int16(sy<<4+y*4), uint8(z*4)))
+					biomeIndices[y*16+z*4+x] = biomes.add(b, b)
+				}
+			}
+		}
+
+		blockPalette := make([]map[string]any, len(blocks.entries))
+		for i, b := range blocks.entries {
+			blockPalette[i] = b.NBT()
+		}
+		blockStates := map[string]any{"palette": blockPalette}
+		if data := packIndices(blockIndices, len(blocks.entries), 4); data != nil {
+			blockStates["data"] = data
+		}
+		biomeStates := map[string]any{"palette": biomes.entries}
+		if data := packIndices(biomeIndices, len(biomes.entries), 1); data != nil {
+			biomeStates["data"] = data
+		}
+
+		sections = append(sections, map[string]any{
+			"Y":            uint8(int8(sy)),
+			"block_states": blockStates,
+			"biomes":       biomeStates,
+		})
+	}
+
+	blockEntities := []map[string]any{}
+	nbts, err := c.w.BlockNBTs(pos)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range nbts {
+		if be := javaBlockEntity(m); be != nil {
+			blockEntities = append(blockEntities, be)
+		}
+	}
+
+	return map[string]any{
+		"DataVersion":    int32(DataVersion),
+		"xPos":           pos[0],
+		"zPos":           pos[1],
+		"yPos":           int32(r.Min() >> 4),
+		"Status":         "minecraft:full",
+		"LastUpdate":     int64(0),
+		"InhabitedTime":  int64(0),
+		"sections":       sections,
+		"block_entities": blockEntities,
+		"isLightOn":      uint8(0),
+	}, nil
+}
+
+// Convert writes the opened world as a java world to folder
+func Convert(w *mcworld.World, folder string, fallback FallbackTable) (*Translator, error) {
+	c := &converter{
+		w:          w,
+		translator: NewTranslator(fallback),
+		blocks:     make(map[uint32]Block),
+	}
+
+	for _, dim := range []world.Dimension{world.Overworld, world.Nether, world.End} {
+		w.Dimension = dim
+		regions := newRegionWriter(filepath.Join(folder, dimensionFolders[dim]))
+		count := 0
+		err := w.Chunks(func(pos world.ChunkPos, ch *chunk.Chunk) error {
+			m, err := c.chunkNBT(pos, ch)
+			if err != nil {
+				return err
+			}
+			count++
+			return regions.add(pos, m)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = regions.write(); err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			logrus.Infof("Converted %d chunks in %s", count, dim)
+		}
+	}
+
+	err := c.writeLevelDat(folder)
+	if err != nil {
+		return nil, err
+	}
+	return c.translator, nil
+}
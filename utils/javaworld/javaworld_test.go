@@ -0,0 +1,66 @@
+package javaworld
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackIndices(t *testing.T) {
+	type test struct {
+		count       int
+		paletteSize int
+		minBits     int
+		bits        int
+	}
+
+	var tests = []test{
+		{count: 4096, paletteSize: 2, minBits: 4, bits: 4},
+		{count: 4096, paletteSize: 16, minBits: 4, bits: 4},
+		{count: 4096, paletteSize: 17, minBits: 4, bits: 5},
+		{count: 4096, paletteSize: 100, minBits: 4, bits: 7},
+		{count: 4096, paletteSize: 4096, minBits: 4, bits: 12},
+		{count: 64, paletteSize: 2, minBits: 1, bits: 1},
+		{count: 64, paletteSize: 3, minBits: 1, bits: 2},
+		{count: 64, paletteSize: 64, minBits: 1, bits: 6},
+	}
+
+	for _, tc := range tests {
+		indices := make([]int, tc.count)
+		for i := range indices {
+			// uses every bit of the entries
+			indices[i] = (i * 7919) % tc.paletteSize
+			if i%5 == 0 {
+				indices[i] = tc.paletteSize - 1
+			}
+		}
+
+		v := reflect.ValueOf(packIndices(indices, tc.paletteSize, tc.minBits))
+		if v.Kind() != reflect.Array {
+			t.Fatalf("palette %d: not a long array: %v", tc.paletteSize, v.Kind())
+		}
+		perLong := 64 / tc.bits
+		if n := (tc.count + perLong - 1) / perLong; v.Len() != n {
+			t.Errorf("palette %d: %d longs, expected %d", tc.paletteSize, v.Len(), n)
+		}
+
+		mask := uint64(1)<<tc.bits - 1
+		for i, idx := range indices {
+			l := uint64(v.Index(i / perLong).Int())
+			if got := int(l >> ((i % perLong) * tc.bits) & mask); got != idx {
+				t.Fatalf("palette %d: entry %d is %d, expected %d", tc.paletteSize, i, got, idx)
+			}
+		}
+		// the bits after the last whole entry are padding
+		if used := perLong * tc.bits; used < 64 {
+			for i := 0; i < v.Len(); i++ {
+				if l := uint64(v.Index(i).Int()); l>>used != 0 {
+					t.Errorf("palette %d: long %d has an entry across the padding: %064b", tc.paletteSize, i, l)
+				}
+			}
+		}
+	}
+
+	if packIndices(make([]int, 4096), 1, 4) != nil {
+		t.Error("a single entry palette should have no data")
+	}
+}
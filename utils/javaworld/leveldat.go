@@ -0,0 +1,156 @@
+package javaworld
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+// gamerules that exist on java, bedrock stores them lowercase
+var javaGameRules = []string{
+	"commandBlockOutput", "doDaylightCycle", "doEntityDrops", "doFireTick", "doImmediateRespawn",
+	"doInsomnia", "doLimitedCrafting", "doMobLoot", "doMobSpawning", "doTileDrops", "doWeatherCycle",
+	"drowningDamage", "fallDamage", "fireDamage", "freezeDamage", "keepInventory", "mobGriefing",
+	"naturalRegeneration", "randomTickSpeed", "sendCommandFeedback", "showDeathMessages",
+	"spawnRadius", "functionCommandLimit", "maxCommandChainLength", "playersSleepingPercentage",
+	"respawnBlocksExplode", "showCoordinates",
+}
+
+func ruleString(v any) string {
+	switch v := v.(type) {
+	case uint8:
+		return strconv.FormatBool(v != 0)
+	case bool:
+		return strconv.FormatBool(v)
+	case int32:
+		return strconv.Itoa(int(v))
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float32:
+		return strconv.Itoa(int(v))
+	default:
+		return ""
+	}
+}
+
+func int32Of(v any) int32 {
+	switch v := v.(type) {
+	case int32:
+		return v
+	case int64:
+		return int32(v)
+	case uint8:
+		return int32(v)
+	default:
+		return 0
+	}
+}
+
+// voidDimension is a flat generator without layers so nothing generates outside the converted chunks
+func voidDimension(dimType string) map[string]any {
+	return map[string]any{
+		"type": dimType,
+		"generator": map[string]any{
+			"type": "minecraft:flat",
+			"settings": map[string]any{
+				"biome":    "minecraft:the_void",
+				"layers":   []any{},
+				"lakes":    uint8(0),
+				"features": uint8(0),
+			},
+		},
+	}
+}
+
+func (c *converter) writeLevelDat(folder string) error {
+	ld, err := c.w.LevelDat()
+	if err != nil {
+		return err
+	}
+
+	gameRules := make(map[string]any)
+	for _, name := range javaGameRules {
+		if v, ok := ld[strings.ToLower(name)]; ok {
+			if s := ruleString(v); s != "" {
+				gameRules[name] = s
+			}
+		}
+	}
+
+	levelName, _ := ld["LevelName"].(string)
+	seed, _ := ld["RandomSeed"].(int64)
+	dayTime, _ := ld["Time"].(int64)
+	rainLevel, _ := ld["rainLevel"].(float32)
+	lightningLevel, _ := ld["lightningLevel"].(float32)
+	commands, _ := ld["commandsEnabled"].(uint8)
+	hardcore, _ := ld["IsHardcore"].(uint8)
+	gameType := int32Of(ld["GameType"])
+	// java has no default gamemode
+	if gameType > 3 {
+		gameType = 0
+	}
+
+	data := map[string]any{
+		"DataVersion": int32(DataVersion),
+		"version":     int32(19133),
+		"Version": map[string]any{
+			"Id":       int32(DataVersion),
+			"Name":     VersionName,
+			"Series":   "main",
+			"Snapshot": uint8(0),
+		},
+		"LevelName":     levelName,
+		"LastPlayed":    time.Now().UnixMilli(),
+		"SpawnX":        int32Of(ld["SpawnX"]),
+		"SpawnY":        int32Of(ld["SpawnY"]),
+		"SpawnZ":        int32Of(ld["SpawnZ"]),
+		"GameType":      gameType,
+		"Difficulty":    uint8(int32Of(ld["Difficulty"])),
+		"hardcore":      hardcore,
+		"allowCommands": commands,
+		"Time":          dayTime,
+		"DayTime":       dayTime,
+		"raining":       boolByte(rainLevel > 0),
+		"thundering":    boolByte(lightningLevel > 0),
+		"initialized":   uint8(1),
+		"GameRules":     gameRules,
+		"DataPacks": map[string]any{
+			"Enabled":  []any{"vanilla"},
+			"Disabled": []any{},
+		},
+		"WorldGenSettings": map[string]any{
+			"seed":              seed,
+			"generate_features": uint8(0),
+			"bonus_chest":       uint8(0),
+			"dimensions": map[string]any{
+				"minecraft:overworld":  voidDimension("minecraft:overworld"),
+				"minecraft:the_nether": voidDimension("minecraft:the_nether"),
+				"minecraft:the_end":    voidDimension("minecraft:the_end"),
+			},
+		},
+	}
+
+	f, err := os.Create(filepath.Join(folder, "level.dat"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	err = nbt.NewEncoderWithEncoding(zw, nbt.BigEndian).Encode(map[string]any{"Data": data})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func boolByte(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package javaworld
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+const sectorSize = 4096
+
+// region holds the compressed chunks of one r.x.z.mca file
+type region struct {
+	chunks [1024][]byte
+}
+
+type regionWriter struct {
+	folder  string
+	regions map[[2]int32]*region
+}
+
+func newRegionWriter(folder string) *regionWriter {
+	return &regionWriter{
+		folder:  folder,
+		regions: make(map[[2]int32]*region),
+	}
+}
+
+// add compresses the chunk nbt and stores it in its region
+func (r *regionWriter) add(pos world.ChunkPos, chunkNBT map[string]any) error {
+	data, err := nbt.MarshalEncoding(chunkNBT, nbt.BigEndian)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	rp := [2]int32{pos[0] >> 5, pos[1] >> 5}
+	reg, ok := r.regions[rp]
+	if !ok {
+		reg = &region{}
+		r.regions[rp] = reg
+	}
+	reg.chunks[(pos[0]&31)+(pos[1]&31)*32] = buf.Bytes()
+	return nil
+}
+
+// write writes all region files
+func (r *regionWriter) write() error {
+	if len(r.regions) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(r.folder, 0o777); err != nil {
+		return err
+	}
+	now := uint32(time.Now().Unix())
+	for rp, reg := range r.regions {
+		header := make([]byte, sectorSize*2)
+		var body bytes.Buffer
+		sector := 2
+		for i, data := range reg.chunks {
+			if data == nil {
+				continue
+			}
+			// length, compression type 2 is zlib
+			var head [5]byte
+			binary.BigEndian.PutUint32(head[:], uint32(len(data)+1))
+			head[4] = 2
+			body.Write(head[:])
+			body.Write(data)
+			size := len(data) + len(head)
+			sectors := (size + sectorSize - 1) / sectorSize
+			if sectors > 255 {
+				return fmt.Errorf("chunk %d in region %v is too large", i, rp)
+			}
+			body.Write(make([]byte, sectors*sectorSize-size))
+
+			binary.BigEndian.PutUint32(header[i*4:], uint32(sector)<<8|uint32(sectors))
+			binary.BigEndian.PutUint32(header[sectorSize+i*4:], now)
+			sector += sectors
+		}
+
+		filename := filepath.Join(r.folder, fmt.Sprintf("r.%d.%d.mca", rp[0], rp[1]))
+		err := os.WriteFile(filename, append(header, body.Bytes()...), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+// Package mcworld reads worlds that were saved by bedrocktool, from a .mcworld file or a world folder
+package mcworld
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+	"github.com/df-mc/dragonfly/server/world/mcdb/leveldat"
+	"github.com/df-mc/goleveldb/leveldb"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	keyVersion       = ','
+	keyVersionOld    = 'v'
+	keyBlockEntities = '1'
+	keyEntities      = '2'
+)
+
+type World struct {
+	Provider  *mcdb.DB
+	Dimension world.Dimension
+	Blocks    world.BlockRegistry
+	Biomes    *world.BiomeRegistry
+	// custom blocks from the behaviour pack of the world
+	CustomBlocks []protocol.BlockEntry
+	// folder the world was opened from, extracted to a temporary folder for .mcworld files
+	Folder string
+	tmp    bool
+}
+
+// Open opens a world folder or .mcworld file, br may be nil to use the default blocks
+func Open(filename string, br world.BlockRegistry) (*World, error) {
+	w := &World{
+		Dimension: world.Overworld,
+		Folder:    filename,
+	}
+
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		w.Folder, err = os.MkdirTemp("", "bedrocktool-world-")
+		if err != nil {
+			return nil, err
+		}
+		w.tmp = true
+		err = extractZip(filename, w.Folder)
+		if err != nil {
+			os.RemoveAll(w.Folder)
+			return nil, err
+		}
+	}
+
+	w.CustomBlocks = readCustomBlocks(w.Folder)
+	if br == nil {
+		br = world.DefaultBlockRegistry
+		if len(w.CustomBlocks) > 0 {
+			br = customBlockRegistry(w.CustomBlocks)
+		}
+	}
+	w.Blocks = br
+	w.Biomes = world.DefaultBiomes
+	w.Provider, err = mcdb.Config{
+		Log:    logrus.StandardLogger(),
+		Blocks: w.Blocks,
+		Biomes: w.Biomes,
+	}.Open(w.Folder)
+	if err != nil {
+		if w.tmp {
+			os.RemoveAll(w.Folder)
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+func extractZip(filename, folder string) error {
+	z, err := zip.OpenReader(filename)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	for _, f := range z.File {
+		name := filepath.Join(folder, filepath.FromSlash(f.Name))
+		if !strings.HasPrefix(name, filepath.Clean(folder)+string(os.PathSeparator)) {
+			continue
+		}
+		if f.FileInfo().IsDir() {
+			os.MkdirAll(name, 0o777)
+			continue
+		}
+		os.MkdirAll(filepath.Dir(name), 0o777)
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		out, err := os.Create(name)
+		if err != nil {
+			r.Close()
+			return err
+		}
+		_, err = io.Copy(out, r)
+		r.Close()
+		out.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the db, extracted worlds are removed
+func (w *World) Close() error {
+	err := w.Provider.Close()
+	if w.tmp {
+		os.RemoveAll(w.Folder)
+	}
+	return err
+}
+
+// Chunks calls fn for every chunk in the current dimension
+func (w *World) Chunks(fn func(pos world.ChunkPos, ch *chunk.Chunk) error) error {
+	iter := w.Provider.NewColumnIterator(&mcdb.IteratorRange{Dimension: w.Dimension})
+	defer iter.Release()
+	for iter.Next() {
+		if err := fn(iter.Position(), iter.Column().Chunk); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+func (w *World) LoadChunk(pos world.ChunkPos) (*chunk.Chunk, bool, error) {
+	col, err := w.Provider.LoadColumn(pos, w.Dimension)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return col.Chunk, true, nil
+}
+
+// ChunkPositions lists the chunks in the current dimension without loading them
+func (w *World) ChunkPositions() ([]world.ChunkPos, error) {
+	dim, _ := world.DimensionID(w.Dimension)
+	keyLen := 9
+	if dim != 0 {
+		keyLen = 13
+	}
+	var out []world.ChunkPos
+	iter := w.Provider.LDB().NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		k := iter.Key()
+		if len(k) != keyLen || (k[keyLen-1] != keyVersion && k[keyLen-1] != keyVersionOld) {
+			continue
+		}
+		if keyLen == 13 && int32(binary.LittleEndian.Uint32(k[8:])) != int32(dim) {
+			continue
+		}
+		out = append(out, world.ChunkPos{
+			int32(binary.LittleEndian.Uint32(k)),
+			int32(binary.LittleEndian.Uint32(k[4:])),
+		})
+	}
+	return out, iter.Error()
+}
+
+// index is the leveldb key prefix of a chunk
+func (w *World) index(pos world.ChunkPos) []byte {
+	dim, _ := world.DimensionID(w.Dimension)
+	b := make([]byte, 12)
+	binary.LittleEndian.PutUint32(b, uint32(pos[0]))
+	binary.LittleEndian.PutUint32(b[4:], uint32(pos[1]))
+	if dim == 0 {
+		return b[:8]
+	}
+	binary.LittleEndian.PutUint32(b[8:], uint32(dim))
+	return b
+}
+
+// decodeAll reads all nbt compounds that are stored after each other
+func decodeAll(data []byte) ([]map[string]any, error) {
+	var out []map[string]any
+	dec := nbt.NewDecoderWithEncoding(bytes.NewBuffer(data), nbt.LittleEndian)
+	for {
+		var m map[string]any
+		err := dec.Decode(&m)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return out, nil
+			}
+			return out, err
+		}
+		out = append(out, m)
+	}
+}
+
+// BlockNBTs returns the block entities of a chunk by position
+func (w *World) BlockNBTs(pos world.ChunkPos) (map[cube.Pos]map[string]any, error) {
+	data, err := w.Provider.LDB().Get(append(w.index(pos), keyBlockEntities), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	list, err := decodeAll(data)
+	out := make(map[cube.Pos]map[string]any, len(list))
+	for _, m := range list {
+		x, _ := m["x"].(int32)
+		y, _ := m["y"].(int32)
+		z, _ := m["z"].(int32)
+		out[cube.Pos{int(x), int(y), int(z)}] = m
+	}
+	return out, err
+}
+
+// Entities returns the entities of a chunk, from the actor storage or the legacy per chunk key
+func (w *World) Entities(pos world.ChunkPos) ([]map[string]any, error) {
+	ldb := w.Provider.LDB()
+	index := w.index(pos)
+
+	ids, err := ldb.Get(append([]byte("digp"), index...), nil)
+	if err == nil {
+		var out []map[string]any
+		for i := 0; i+8 <= len(ids); i += 8 {
+			data, err := ldb.Get(append([]byte("actorprefix"), ids[i:i+8]...), nil)
+			if err != nil {
+				continue
+			}
+			var m map[string]any
+			if err := nbt.UnmarshalEncoding(data, &m, nbt.LittleEndian); err != nil {
+				logrus.Warnf("entity in %v: %s", pos, err)
+				continue
+			}
+			out = append(out, m)
+		}
+		return out, nil
+	} else if !errors.Is(err, leveldb.ErrNotFound) {
+		return nil, err
+	}
+
+	data, err := ldb.Get(append(index, keyEntities), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeAll(data)
+}
+
+// LevelDat reads all keys of the level.dat, including the ones leveldat.Data doesnt have
+func (w *World) LevelDat() (map[string]any, error) {
+	ldat, err := leveldat.ReadFile(filepath.Join(w.Folder, "level.dat"))
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = ldat.Unmarshal(&m)
+	return m, err
+}
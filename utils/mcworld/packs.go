@@ -0,0 +1,82 @@
+package mcworld
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+	"github.com/sirupsen/logrus"
+)
+
+// readCustomBlocks reads the block definitions from the behaviour packs in the world,
+// only the identifier and components are saved so custom block states are lost
+func readCustomBlocks(folder string) []protocol.BlockEntry {
+	var entries []protocol.BlockEntry
+	packs, _ := os.ReadDir(filepath.Join(folder, "behavior_packs"))
+	for _, pack := range packs {
+		blocksDir := filepath.Join(folder, "behavior_packs", pack.Name(), "blocks")
+		filepath.WalkDir(blocksDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(p, ".json") {
+				return nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return nil
+			}
+			var def struct {
+				Block struct {
+					Description struct {
+						Identifier string `json:"identifier"`
+					} `json:"description"`
+					Components map[string]any `json:"components"`
+				} `json:"minecraft:block"`
+			}
+			if err := utils.ParseJson(data, &def); err != nil {
+				logrus.Warnf("%s: %s", p, err)
+				return nil
+			}
+			if def.Block.Description.Identifier == "" {
+				return nil
+			}
+			entry := protocol.BlockEntry{
+				Name:       def.Block.Description.Identifier,
+				Properties: map[string]any{},
+			}
+			if def.Block.Components != nil {
+				entry.Properties["components"] = def.Block.Components
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	}
+	return entries
+}
+
+// customBlockRegistry returns the default blocks with the custom blocks of the world added
+func customBlockRegistry(entries []protocol.BlockEntry) world.BlockRegistry {
+	br := world.DefaultBlockRegistry.Clone().(*world.BlockRegistryImpl)
+	world.AddCustomBlocks(br, entries)
+	br.Finalize()
+	return br
+}
+
+// ResourcePacks reads the resource packs that were saved with the world
+func (w *World) ResourcePacks() []utils.Pack {
+	var packs []utils.Pack
+	dirs, _ := os.ReadDir(filepath.Join(w.Folder, "resource_packs"))
+	for _, d := range dirs {
+		pack, err := resource.ReadPath(filepath.Join(w.Folder, "resource_packs", d.Name()))
+		if err != nil {
+			logrus.Warnf("resource pack %s: %s", d.Name(), err)
+			continue
+		}
+		packs = append(packs, utils.PackFromBase(pack))
+	}
+	return packs
+}
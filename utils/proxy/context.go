@@ -24,6 +24,7 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 	"github.com/sandertv/gophertunnel/minecraft/resource"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 	"golang.org/x/oauth2"
 )
 
@@ -50,10 +51,15 @@ type Context struct {
 	serverAddress    string
 	serverName       string
 
-	commands  map[string]ingameCommand
 	handlers  []*Handler
 	transfer  *packet.Transfer
 	rpHandler *rpHandler
+
+	// commands is written by AddCommand from other goroutines, like script reloads
+	commandsLock sync.Mutex
+	commands     map[string]ingameCommand
+	// the last AvailableCommands from the server, without the proxy commands
+	serverCommands *packet.AvailableCommands
 }
 
 // New creates a new proxy context
@@ -66,10 +72,30 @@ func New(withClient bool) (*Context, error) {
 	return p, nil
 }
 
-// AddCommand adds a command to the command handler
+// AddCommand adds a command to the command handler,
+// if the client already has the command list it is sent again with the new command
 func (p *Context) AddCommand(exec func([]string) bool, cmd protocol.Command) {
 	cmd.AliasesOffset = 0xffffffff
+	p.commandsLock.Lock()
 	p.commands[cmd.Name] = ingameCommand{exec, cmd}
+	var pk *packet.AvailableCommands
+	if p.serverCommands != nil {
+		pk = p.availableCommands()
+	}
+	p.commandsLock.Unlock()
+	if pk != nil {
+		_ = p.ClientWritePacket(pk)
+	}
+}
+
+// availableCommands adds the proxy commands to the ones the server sent, commandsLock must be held
+func (p *Context) availableCommands() *packet.AvailableCommands {
+	pk := *p.serverCommands
+	pk.Commands = slices.Clone(pk.Commands)
+	for _, ic := range p.commands {
+		pk.Commands = append(pk.Commands, ic.Cmd)
+	}
+	return &pk
 }
 
 // ClientWritePacket sends a packet to the client, nop if no client connected
@@ -106,16 +132,19 @@ func (p *Context) commandHandlerPacketCB(pk packet.Packet, toServer bool, _ time
 	case *packet.CommandRequest:
 		cmd := strings.Split(_pk.CommandLine, " ")
 		name := cmd[0][1:]
-		if h, ok := p.commands[name]; ok {
+		p.commandsLock.Lock()
+		h, ok := p.commands[name]
+		p.commandsLock.Unlock()
+		// not locked while running, the command may add commands
+		if ok {
 			pk = nil
 			h.Exec(cmd[1:])
 		}
 	case *packet.AvailableCommands:
-		cmds := make([]protocol.Command, 0, len(p.commands))
-		for _, ic := range p.commands {
-			cmds = append(cmds, ic.Cmd)
-		}
-		_pk.Commands = append(_pk.Commands, cmds...)
+		p.commandsLock.Lock()
+		p.serverCommands = _pk
+		pk = p.availableCommands()
+		p.commandsLock.Unlock()
 	}
 	return pk, nil
 }
@@ -500,6 +529,9 @@ func (p *Context) connect(ctx context.Context) (err error) {
 	p.clientAddr = nil
 	p.transfer = nil
 	p.Client = nil
+	p.commandsLock.Lock()
+	p.serverCommands = nil
+	p.commandsLock.Unlock()
 	p.clientConnecting = make(chan struct{})
 	p.haveClientData = make(chan struct{})
 	ctx2, cancel := context.WithCancelCause(ctx)
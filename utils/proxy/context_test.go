@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// commands are added from script reloads while the packet goroutine reads them, run with -race
+func TestAddCommandConcurrent(t *testing.T) {
+	p, err := New(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ran := false
+	p.AddCommand(func([]string) bool {
+		ran = true
+		return true
+	}, protocol.Command{Name: "first"})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			p.AddCommand(func([]string) bool { return true }, protocol.Command{Name: fmt.Sprintf("cmd%d", i)})
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		if _, err := p.commandHandlerPacketCB(&packet.AvailableCommands{}, false, time.Now(), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pk, err := p.commandHandlerPacketCB(&packet.CommandRequest{CommandLine: "/first"}, true, time.Now(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk != nil || !ran {
+		t.Error("the command did not run")
+	}
+	<-done
+
+	pk, _ = p.commandHandlerPacketCB(&packet.AvailableCommands{}, false, time.Now(), false)
+	if n := len(pk.(*packet.AvailableCommands).Commands); n != 1001 {
+		t.Errorf("%d commands sent to the client, expected 1001", n)
+	}
+}
+
+// commands added after the client got the list are sent with the commands of the server
+func TestAddCommandAfterAvailableCommands(t *testing.T) {
+	p, err := New(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.AddCommand(func([]string) bool { return true }, protocol.Command{Name: "before"})
+
+	server := &packet.AvailableCommands{Commands: []protocol.Command{{Name: "give"}}}
+	pk, _ := p.commandHandlerPacketCB(server, false, time.Now(), false)
+	if n := len(pk.(*packet.AvailableCommands).Commands); n != 2 {
+		t.Fatalf("%d commands sent to the client, expected 2", n)
+	}
+	if len(server.Commands) != 1 {
+		t.Error("the proxy commands were added to the packet of the server")
+	}
+
+	p.AddCommand(func([]string) bool { return true }, protocol.Command{Name: "after"})
+	p.commandsLock.Lock()
+	merged := p.availableCommands()
+	p.commandsLock.Unlock()
+	names := make(map[string]bool)
+	for _, cmd := range merged.Commands {
+		names[cmd.Name] = true
+	}
+	if len(merged.Commands) != 3 || !names["give"] || !names["before"] || !names["after"] {
+		t.Errorf("resent commands %v, expected give, before and after", names)
+	}
+}
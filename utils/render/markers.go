@@ -0,0 +1,112 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils/mcworld"
+)
+
+const (
+	MarkerSign     = "sign"
+	MarkerPlayer   = "player"
+	MarkerEntity   = "entity"
+	MarkerWaypoint = "waypoint"
+)
+
+type Marker struct {
+	Type string  `json:"type"`
+	Name string  `json:"name"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Z    float64 `json:"z"`
+}
+
+func signText(m map[string]any) string {
+	if front, ok := m["FrontText"].(map[string]any); ok {
+		m = front
+	}
+	text, _ := m["Text"].(string)
+	return strings.TrimSpace(text)
+}
+
+func blockMarker(m map[string]any) *Marker {
+	id, _ := m["id"].(string)
+	x, _ := m["x"].(int32)
+	y, _ := m["y"].(int32)
+	z, _ := m["z"].(int32)
+	marker := &Marker{X: float64(x) + 0.5, Y: float64(y), Z: float64(z) + 0.5}
+	switch id {
+	case "Sign", "HangingSign":
+		marker.Type = MarkerSign
+		marker.Name = signText(m)
+		if marker.Name == "" {
+			return nil
+		}
+	case "Lodestone":
+		marker.Type = MarkerWaypoint
+		marker.Name = "Lodestone"
+	case "Banner":
+		// named banners are used as waypoints
+		name, _ := m["CustomName"].(string)
+		if name == "" {
+			return nil
+		}
+		marker.Type = MarkerWaypoint
+		marker.Name = name
+	default:
+		return nil
+	}
+	return marker
+}
+
+func entityMarker(m map[string]any) *Marker {
+	identifier, _ := m["identifier"].(string)
+	pos, _ := m["Pos"].([]any)
+	if identifier == "" || len(pos) != 3 {
+		return nil
+	}
+	var p [3]float64
+	for i, v := range pos {
+		f, _ := v.(float32)
+		p[i] = float64(f)
+	}
+	marker := &Marker{Type: MarkerEntity, Name: strings.TrimPrefix(identifier, "minecraft:"), X: p[0], Y: p[1], Z: p[2]}
+	name, _ := m["CustomName"].(string)
+	if strings.HasPrefix(identifier, "player:") {
+		marker.Type = MarkerPlayer
+		marker.Name = name
+	} else if name != "" {
+		marker.Name = name + " (" + marker.Name + ")"
+	}
+	return marker
+}
+
+// ReadMarkers reads signs, waypoints, players and entities in the current dimension
+func ReadMarkers(w *mcworld.World) ([]Marker, error) {
+	positions, err := w.ChunkPositions()
+	if err != nil {
+		return nil, err
+	}
+	markers := []Marker{}
+	for _, cp := range positions {
+		nbts, err := w.BlockNBTs(cp)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range nbts {
+			if marker := blockMarker(m); marker != nil {
+				markers = append(markers, *marker)
+			}
+		}
+		entities, err := w.Entities(cp)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range entities {
+			if marker := entityMarker(m); marker != nil {
+				markers = append(markers, *marker)
+			}
+		}
+	}
+	return markers, nil
+}
@@ -0,0 +1,117 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/bedrock-tool/bedrocktool/utils/mcworld"
+)
+
+// tiles of the pyramid are 256 pixels, 16 chunks at the most detailed level
+const pyramidTileChunks = 16
+
+// Pyramid is a z/x/y tile pyramid of one dimension, z = Levels is 1 pixel per block
+type Pyramid struct {
+	Folder string
+	Levels int
+	Tiles  *Tiles
+}
+
+func pyramidPath(folder string, tp [2]int32) string {
+	return filepath.Join(folder, strconv.Itoa(int(tp[0])), fmt.Sprintf("%d.png", tp[1]))
+}
+
+// RenderPyramid renders the current dimension to folder/z/x/y.png,
+// every level below the most detailed one is half the resolution of the next
+func RenderPyramid(w *mcworld.World, folder string, opts Options) (*Pyramid, error) {
+	opts.TileSize = pyramidTileChunks
+	opts.TilePath = pyramidPath
+	base := filepath.Join(folder, "base")
+	os.RemoveAll(base)
+	tiles, err := RenderTiles(w, base, opts)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pyramid{Folder: folder, Tiles: tiles}
+	if len(tiles.Tiles) == 0 {
+		return p, nil
+	}
+
+	// enough levels that the lowest one fits in 2x2 tiles
+	size := int32(pyramidTileChunks)
+	spanX := floorDiv(tiles.Max[0]-1, size) - floorDiv(tiles.Min[0], size) + 1
+	spanZ := floorDiv(tiles.Max[1]-1, size) - floorDiv(tiles.Min[1], size) + 1
+	p.Levels = bits.Len(uint(max(spanX, spanZ) - 1))
+
+	top := filepath.Join(folder, strconv.Itoa(p.Levels))
+	os.RemoveAll(top)
+	if err = os.Rename(base, top); err != nil {
+		return nil, err
+	}
+
+	current := tiles.Tiles
+	for z := p.Levels - 1; z >= 0; z-- {
+		childFolder := filepath.Join(folder, strconv.Itoa(z+1))
+		levelFolder := filepath.Join(folder, strconv.Itoa(z))
+		parents := make(map[[2]int32]bool)
+		for tp := range current {
+			parents[[2]int32{floorDiv(tp[0], 2), floorDiv(tp[1], 2)}] = true
+		}
+		for tp := range parents {
+			img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+			for dx := int32(0); dx < 2; dx++ {
+				for dz := int32(0); dz < 2; dz++ {
+					child := [2]int32{tp[0]*2 + dx, tp[1]*2 + dz}
+					if !current[child] {
+						continue
+					}
+					childImg, err := readPng(pyramidPath(childFolder, child))
+					if err != nil {
+						return nil, err
+					}
+					downscale(img, childImg, int(dx)*128, int(dz)*128)
+				}
+			}
+			filename := pyramidPath(levelFolder, tp)
+			os.MkdirAll(filepath.Dir(filename), 0o777)
+			if err = writePng(filename, img); err != nil {
+				return nil, err
+			}
+		}
+		current = parents
+	}
+	return p, nil
+}
+
+func readPng(filename string) (image.Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// downscale draws src at half size into dst at ox, oy, averaging 2x2 pixels
+func downscale(dst *image.RGBA, src image.Image, ox, oy int) {
+	b := src.Bounds()
+	for y := 0; y < b.Dy()/2; y++ {
+		for x := 0; x < b.Dx()/2; x++ {
+			var r, g, bl, a uint32
+			for _, d := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				c := color.RGBAModel.Convert(src.At(b.Min.X+x*2+d[0], b.Min.Y+y*2+d[1])).(color.RGBA)
+				r += uint32(c.R)
+				g += uint32(c.G)
+				bl += uint32(c.B)
+				a += uint32(c.A)
+			}
+			dst.SetRGBA(ox+x, oy+y, color.RGBA{uint8(r / 4), uint8(g / 4), uint8(bl / 4), uint8(a / 4)})
+		}
+	}
+}
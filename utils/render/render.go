@@ -0,0 +1,145 @@
+// Package render renders saved worlds to png images, in tiles so the size is not limited by memory
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/bedrock-tool/bedrocktool/utils/mcworld"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ModeTop    = "top"
+	ModeCave   = "cave"
+	ModeHeight = "height"
+)
+
+type Options struct {
+	Mode string
+	// y of the slice for cave mode
+	CaveY int
+	// width and height of a tile in chunks
+	TileSize int
+	// filename of a tile, tile_x_z.png in the folder if nil
+	TilePath func(folder string, tp [2]int32) string
+}
+
+// Tiles is the result of rendering one dimension
+type Tiles struct {
+	Folder   string
+	TileSize int
+	Path     func(folder string, tp [2]int32) string
+	// tile positions that have at least one chunk, in tile coordinates
+	Tiles map[[2]int32]bool
+	// bounds in chunks, max is exclusive
+	Min, Max world.ChunkPos
+}
+
+func (o Options) chunkImage(ch *chunk.Chunk) *image.RGBA {
+	switch o.Mode {
+	case ModeCave:
+		return utils.Chunk2ImgCave(ch, int16(o.CaveY))
+	case ModeHeight:
+		return utils.Chunk2ImgHeight(ch)
+	default:
+		return utils.Chunk2Img(ch)
+	}
+}
+
+func tileFilename(folder string, tp [2]int32) string {
+	return filepath.Join(folder, fmt.Sprintf("tile_%d_%d.png", tp[0], tp[1]))
+}
+
+func writePng(filename string, img image.Image) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+// RenderTiles renders the current dimension of the world to tiles in folder
+func RenderTiles(w *mcworld.World, folder string, opts Options) (*Tiles, error) {
+	if opts.TileSize <= 0 {
+		opts.TileSize = 32
+	}
+	if opts.TilePath == nil {
+		opts.TilePath = tileFilename
+	}
+	positions, err := w.ChunkPositions()
+	if err != nil {
+		return nil, err
+	}
+	t := &Tiles{
+		Folder:   folder,
+		TileSize: opts.TileSize,
+		Path:     opts.TilePath,
+		Tiles:    make(map[[2]int32]bool),
+	}
+	if len(positions) == 0 {
+		return t, nil
+	}
+	if err = os.MkdirAll(folder, 0o777); err != nil {
+		return nil, err
+	}
+
+	size := int32(opts.TileSize)
+	t.Min, t.Max = positions[0], positions[0]
+	tiles := make(map[[2]int32][]world.ChunkPos)
+	for _, cp := range positions {
+		tp := [2]int32{floorDiv(cp[0], size), floorDiv(cp[1], size)}
+		tiles[tp] = append(tiles[tp], cp)
+		t.Min = world.ChunkPos{min(t.Min[0], cp[0]), min(t.Min[1], cp[1])}
+		t.Max = world.ChunkPos{max(t.Max[0], cp[0]), max(t.Max[1], cp[1])}
+	}
+	t.Max = world.ChunkPos{t.Max[0] + 1, t.Max[1] + 1}
+
+	for tp, chunks := range tiles {
+		img := image.NewRGBA(image.Rect(0, 0, opts.TileSize*16, opts.TileSize*16))
+		for _, cp := range chunks {
+			ch, found, err := w.LoadChunk(cp)
+			if err != nil {
+				logrus.Warnf("chunk %v: %s", cp, err)
+				continue
+			}
+			if !found {
+				continue
+			}
+			px := int(cp[0]-tp[0]*size) * 16
+			pz := int(cp[1]-tp[1]*size) * 16
+			draw.Draw(img, image.Rect(px, pz, px+16, pz+16), opts.chunkImage(ch), image.Point{}, draw.Src)
+		}
+		filename := opts.TilePath(folder, tp)
+		os.MkdirAll(filepath.Dir(filename), 0o777)
+		if err := writePng(filename, img); err != nil {
+			return nil, err
+		}
+		t.Tiles[tp] = true
+	}
+	return t, nil
+}
+
+func floorDiv(a, b int32) int32 {
+	d := a / b
+	if a%b != 0 && a < 0 {
+		d--
+	}
+	return d
+}
+
+// Stitch writes all tiles as one png without having the whole image in memory
+func (t *Tiles) Stitch(filename string) error {
+	if len(t.Tiles) == 0 {
+		return nil
+	}
+	return writePng(filename, newTiledImage(t))
+}
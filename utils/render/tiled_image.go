@@ -0,0 +1,72 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+)
+
+// tiledImage is an image.Image over the tile files, png.Encode reads it row by row
+// so only the tiles of the current row are kept loaded
+type tiledImage struct {
+	t        *Tiles
+	bounds   image.Rectangle
+	tilePx   int
+	minTile  [2]int32
+	row      int32
+	rowTiles map[int32]image.Image
+}
+
+func newTiledImage(t *Tiles) *tiledImage {
+	size := int32(t.TileSize)
+	minTile := [2]int32{floorDiv(t.Min[0], size), floorDiv(t.Min[1], size)}
+	// crop to the chunks that exist
+	offX := int(t.Min[0]-minTile[0]*size) * 16
+	offZ := int(t.Min[1]-minTile[1]*size) * 16
+	return &tiledImage{
+		t:       t,
+		tilePx:  t.TileSize * 16,
+		minTile: minTile,
+		row:     -1 << 31,
+		bounds: image.Rect(
+			offX, offZ,
+			offX+int(t.Max[0]-t.Min[0])*16, offZ+int(t.Max[1]-t.Min[1])*16,
+		),
+	}
+}
+
+func (ti *tiledImage) ColorModel() color.Model { return color.RGBAModel }
+func (ti *tiledImage) Bounds() image.Rectangle { return ti.bounds }
+
+func (ti *tiledImage) loadRow(row int32) {
+	ti.row = row
+	ti.rowTiles = make(map[int32]image.Image)
+	for tp := range ti.t.Tiles {
+		if tp[1] != row {
+			continue
+		}
+		f, err := os.Open(ti.t.Path(ti.t.Folder, tp))
+		if err != nil {
+			continue
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err == nil {
+			ti.rowTiles[tp[0]] = img
+		}
+	}
+}
+
+func (ti *tiledImage) At(x, y int) color.Color {
+	tx := ti.minTile[0] + int32(x/ti.tilePx)
+	tz := ti.minTile[1] + int32(y/ti.tilePx)
+	if tz != ti.row {
+		ti.loadRow(tz)
+	}
+	img, ok := ti.rowTiles[tx]
+	if !ok {
+		return color.RGBA{}
+	}
+	return img.At(x%ti.tilePx, y%ti.tilePx)
+}
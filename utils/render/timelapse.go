@@ -0,0 +1,135 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bedrock-tool/bedrocktool/utils/chunkhistory"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// the largest time-lapse frame, bigger areas have to be limited with AreaMin and AreaMax
+const maxTimelapseSize = 8192
+
+type TimelapseOptions struct {
+	Options
+	Dimension world.Dimension
+	// only render chunks in AreaMin to AreaMax, max is inclusive
+	HasArea          bool
+	AreaMin, AreaMax world.ChunkPos
+	// changes within the interval are merged into one frame
+	Interval time.Duration
+	// write an animated gif next to the frames
+	Gif bool
+}
+
+func (o TimelapseOptions) inArea(pos world.ChunkPos) bool {
+	if !o.HasArea {
+		return true
+	}
+	return pos[0] >= o.AreaMin[0] && pos[0] <= o.AreaMax[0] && pos[1] >= o.AreaMin[1] && pos[1] <= o.AreaMax[1]
+}
+
+// RenderTimelapse replays the chunk history and writes folder/frame_00000.png for every interval that had changes
+func RenderTimelapse(historyFolder, folder string, br world.BlockRegistry, opts TimelapseOptions) (int, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	r, err := chunkhistory.Open(historyFolder)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	dimID, _ := world.DimensionID(opts.Dimension)
+	var versions []chunkhistory.Version
+	for _, v := range r.Versions {
+		if v.Dimension == dimID && opts.inArea(v.Pos()) {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return 0, nil
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].Time < versions[j].Time })
+
+	lo, hi := versions[0].Pos(), versions[0].Pos()
+	for _, v := range versions {
+		lo = world.ChunkPos{min(lo[0], v.X), min(lo[1], v.Z)}
+		hi = world.ChunkPos{max(hi[0], v.X), max(hi[1], v.Z)}
+	}
+	width, height := int(hi[0]-lo[0]+1)*16, int(hi[1]-lo[1]+1)*16
+	if width > maxTimelapseSize || height > maxTimelapseSize {
+		return 0, fmt.Errorf("time-lapse would be %dx%d pixels, limit the area", width, height)
+	}
+	if err = os.MkdirAll(folder, 0o777); err != nil {
+		return 0, err
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	var anim gif.GIF
+	frames := 0
+	writeFrame := func() error {
+		err := writePng(filepath.Join(folder, fmt.Sprintf("frame_%05d.png", frames)), canvas)
+		if err != nil {
+			return err
+		}
+		frames++
+		if opts.Gif {
+			p := image.NewPaletted(canvas.Rect, palette.Plan9)
+			draw.FloydSteinberg.Draw(p, p.Rect, canvas, image.Point{})
+			anim.Image = append(anim.Image, p)
+			anim.Delay = append(anim.Delay, 10)
+		}
+		return nil
+	}
+
+	interval := opts.Interval.Milliseconds()
+	start := versions[0].Time
+	frameEnd := start + interval
+	changed := false
+	for _, v := range versions {
+		if v.Time >= frameEnd {
+			if changed {
+				if err = writeFrame(); err != nil {
+					return frames, err
+				}
+				changed = false
+			}
+			frameEnd = v.Time - (v.Time-start)%interval + interval
+		}
+		ch, err := r.Load(v, br)
+		if err != nil {
+			return frames, fmt.Errorf("chunk %v at %d: %w", v.Pos(), v.Time, err)
+		}
+		px := image.Pt(int(v.X-lo[0])*16, int(v.Z-lo[1])*16)
+		draw.Draw(canvas, image.Rect(px.X, px.Y, px.X+16, px.Y+16), opts.chunkImage(ch), image.Point{}, draw.Src)
+		changed = true
+	}
+	if changed {
+		if err = writeFrame(); err != nil {
+			return frames, err
+		}
+	}
+
+	if opts.Gif {
+		// hold the last frame
+		anim.Delay[len(anim.Delay)-1] = 200
+		f, err := os.Create(filepath.Join(folder, "timelapse.gif"))
+		if err != nil {
+			return frames, err
+		}
+		defer f.Close()
+		if err = gif.EncodeAll(f, &anim); err != nil {
+			return frames, err
+		}
+	}
+	return frames, nil
+}
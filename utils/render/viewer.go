@@ -0,0 +1,44 @@
+package render
+
+import (
+	_ "embed"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+//go:embed viewer.html
+var viewerHTML []byte
+
+// DimensionConfig tells the viewer where the tiles of a dimension are
+type DimensionConfig struct {
+	Levels int            `json:"levels"`
+	Min    world.ChunkPos `json:"min"`
+	Max    world.ChunkPos `json:"max"`
+}
+
+// WriteViewer writes index.html and the config and markers it loads from js files,
+// so it also works when opened from disk
+func WriteViewer(folder string, dimensions map[string]DimensionConfig, markers map[string][]Marker) error {
+	writeJS := func(name, variable string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		content := append([]byte("window."+variable+" = "), data...)
+		content = append(content, ";\n"...)
+		return os.WriteFile(filepath.Join(folder, name), content, 0o644)
+	}
+
+	err := writeJS("config.js", "mapConfig", map[string]any{"dimensions": dimensions})
+	if err != nil {
+		return err
+	}
+	err = writeJS("markers.js", "mapMarkers", markers)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(folder, "index.html"), viewerHTML, 0o644)
+}
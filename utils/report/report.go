@@ -0,0 +1,320 @@
+// Package report counts what is in a saved world, blocks, ores, entities, containers and texts
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils/mcworld"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+)
+
+type Report struct {
+	Name       string                      `json:"name"`
+	Dimensions map[string]*DimensionReport `json:"dimensions"`
+	// blocks and items that are not from the minecraft namespace, with how often they were found
+	CustomBlocks map[string]int `json:"custom_blocks"`
+	CustomItems  map[string]int `json:"custom_items"`
+}
+
+type Bounds struct {
+	Min world.ChunkPos `json:"min"`
+	Max world.ChunkPos `json:"max"`
+}
+
+type DimensionReport struct {
+	Chunks int    `json:"chunks"`
+	Bounds Bounds `json:"bounds"`
+	// block counts without air, total and by y level
+	Blocks    map[string]int         `json:"blocks"`
+	BlocksByY map[int]map[string]int `json:"blocks_by_y"`
+	// ore counts by y level
+	Ores       map[string]map[int]int   `json:"ores"`
+	Entities   map[string]*EntityCensus `json:"entities"`
+	Containers []Container              `json:"containers"`
+	// totals of all items in the containers
+	Items map[string]int `json:"items"`
+	Texts []Text         `json:"texts"`
+}
+
+type EntityCensus struct {
+	Count     int          `json:"count"`
+	Positions [][3]float32 `json:"positions"`
+}
+
+type Container struct {
+	Type  string         `json:"type"`
+	Pos   [3]float32     `json:"pos"`
+	Name  string         `json:"name,omitempty"`
+	Items map[string]int `json:"items"`
+}
+
+const (
+	TextSign    = "sign"
+	TextBook    = "book"
+	TextLectern = "lectern"
+)
+
+type Text struct {
+	Type   string     `json:"type"`
+	Pos    [3]float32 `json:"pos"`
+	Title  string     `json:"title,omitempty"`
+	Author string     `json:"author,omitempty"`
+	Text   string     `json:"text"`
+}
+
+func isOre(name string) bool {
+	return strings.HasSuffix(name, "_ore") || name == "minecraft:ancient_debris"
+}
+
+func isCustom(name string) bool {
+	return !strings.HasPrefix(name, "minecraft:")
+}
+
+// Generate reads every dimension of the world
+func Generate(w *mcworld.World, name string) (*Report, error) {
+	r := &Report{
+		Name:         name,
+		Dimensions:   make(map[string]*DimensionReport),
+		CustomBlocks: make(map[string]int),
+		CustomItems:  make(map[string]int),
+	}
+	for _, dim := range []world.Dimension{world.Overworld, world.Nether, world.End} {
+		w.Dimension = dim
+		d, err := r.dimension(w)
+		if err != nil {
+			return nil, err
+		}
+		if d.Chunks > 0 {
+			r.Dimensions[fmt.Sprint(dim)] = d
+		}
+	}
+	w.Dimension = world.Overworld
+	return r, nil
+}
+
+func (r *Report) dimension(w *mcworld.World) (*DimensionReport, error) {
+	d := &DimensionReport{
+		Blocks:     make(map[string]int),
+		BlocksByY:  make(map[int]map[string]int),
+		Ores:       make(map[string]map[int]int),
+		Entities:   make(map[string]*EntityCensus),
+		Containers: []Container{},
+		Items:      make(map[string]int),
+		Texts:      []Text{},
+	}
+	names := make(map[uint32]string)
+	err := w.Chunks(func(pos world.ChunkPos, ch *chunk.Chunk) error {
+		if d.Chunks == 0 {
+			d.Bounds = Bounds{pos, pos}
+		}
+		d.Chunks++
+		d.Bounds.Min = world.ChunkPos{min(d.Bounds.Min[0], pos[0]), min(d.Bounds.Min[1], pos[1])}
+		d.Bounds.Max = world.ChunkPos{max(d.Bounds.Max[0], pos[0]), max(d.Bounds.Max[1], pos[1])}
+		r.countBlocks(d, ch, names)
+
+		nbts, err := w.BlockNBTs(pos)
+		if err != nil {
+			return err
+		}
+		for p, m := range nbts {
+			r.blockEntity(d, p, m)
+		}
+		entities, err := w.Entities(pos)
+		if err != nil {
+			return err
+		}
+		for _, m := range entities {
+			r.entity(d, m)
+		}
+		return nil
+	})
+	return d, err
+}
+
+func (r *Report) countBlocks(d *DimensionReport, ch *chunk.Chunk, names map[uint32]string) {
+	br := ch.BlockRegistry.(world.BlockRegistry)
+	for i, sub := range ch.Sub() {
+		if sub.Empty() {
+			continue
+		}
+		baseY := ch.SubY(int16(i))
+		for y := int16(0); y < 16; y++ {
+			for x := uint8(0); x < 16; x++ {
+				for z := uint8(0); z < 16; z++ {
+					rid := ch.Block(x, baseY+y, z, 0)
+					name, ok := names[rid]
+					if !ok {
+						if b, found := br.BlockByRuntimeID(rid); found {
+							name, _ = b.EncodeBlock()
+						} else {
+							name = "unknown"
+						}
+						names[rid] = name
+					}
+					if name == "minecraft:air" {
+						continue
+					}
+					wy := int(baseY + y)
+					d.Blocks[name]++
+					byY, ok := d.BlocksByY[wy]
+					if !ok {
+						byY = make(map[string]int)
+						d.BlocksByY[wy] = byY
+					}
+					byY[name]++
+					if isOre(name) {
+						ores, ok := d.Ores[name]
+						if !ok {
+							ores = make(map[int]int)
+							d.Ores[name] = ores
+						}
+						ores[wy]++
+					}
+					if isCustom(name) {
+						r.CustomBlocks[name]++
+					}
+				}
+			}
+		}
+	}
+}
+
+func blockPos(p cube.Pos) [3]float32 {
+	return [3]float32{float32(p[0]), float32(p[1]), float32(p[2])}
+}
+
+func (r *Report) blockEntity(d *DimensionReport, p cube.Pos, m map[string]any) {
+	id, _ := m["id"].(string)
+	switch id {
+	case "Sign", "HangingSign":
+		for _, side := range []string{"FrontText", "BackText"} {
+			s, ok := m[side].(map[string]any)
+			if !ok {
+				continue
+			}
+			if text, _ := s["Text"].(string); strings.TrimSpace(text) != "" {
+				d.Texts = append(d.Texts, Text{Type: TextSign, Pos: blockPos(p), Text: text})
+			}
+		}
+		// signs before 1.20
+		if text, _ := m["Text"].(string); strings.TrimSpace(text) != "" {
+			d.Texts = append(d.Texts, Text{Type: TextSign, Pos: blockPos(p), Text: text})
+		}
+	case "Lectern":
+		if book, ok := m["book"].(map[string]any); ok {
+			if t, ok := bookText(book); ok {
+				t.Type = TextLectern
+				t.Pos = blockPos(p)
+				d.Texts = append(d.Texts, t)
+			}
+		}
+	}
+	if items, ok := m["Items"].([]any); ok {
+		name, _ := m["CustomName"].(string)
+		r.container(d, id, blockPos(p), name, items)
+	}
+}
+
+func (r *Report) entity(d *DimensionReport, m map[string]any) {
+	identifier, _ := m["identifier"].(string)
+	if identifier == "" {
+		return
+	}
+	var pos [3]float32
+	if p, ok := m["Pos"].([]any); ok && len(p) == 3 {
+		for i, v := range p {
+			pos[i], _ = v.(float32)
+		}
+	}
+	census, ok := d.Entities[identifier]
+	if !ok {
+		census = &EntityCensus{}
+		d.Entities[identifier] = census
+	}
+	census.Count++
+	census.Positions = append(census.Positions, pos)
+
+	// chest minecarts, donkeys and llamas
+	if items, ok := m["ChestItems"].([]any); ok {
+		name, _ := m["CustomName"].(string)
+		r.container(d, identifier, pos, name, items)
+	}
+}
+
+func (r *Report) container(d *DimensionReport, typ string, pos [3]float32, name string, items []any) {
+	c := Container{Type: typ, Pos: pos, Name: name, Items: make(map[string]int)}
+	for _, it := range items {
+		item, ok := it.(map[string]any)
+		if !ok {
+			continue
+		}
+		itemName, _ := item["Name"].(string)
+		count, _ := item["Count"].(uint8)
+		if itemName == "" || itemName == "minecraft:air" || count == 0 {
+			continue
+		}
+		c.Items[itemName] += int(count)
+		d.Items[itemName] += int(count)
+		if isCustom(itemName) {
+			r.CustomItems[itemName] += int(count)
+		}
+		if t, ok := bookText(item); ok {
+			t.Type = TextBook
+			t.Pos = pos
+			d.Texts = append(d.Texts, t)
+		}
+	}
+	if len(c.Items) > 0 {
+		d.Containers = append(d.Containers, c)
+	}
+}
+
+// bookText reads the pages of a book and quill or written book item
+func bookText(item map[string]any) (Text, bool) {
+	tag, ok := item["tag"].(map[string]any)
+	if !ok {
+		return Text{}, false
+	}
+	pages, _ := tag["pages"].([]any)
+	var texts []string
+	for _, p := range pages {
+		page, _ := p.(map[string]any)
+		if text, _ := page["text"].(string); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		return Text{}, false
+	}
+	t := Text{Text: strings.Join(texts, "\n\n")}
+	t.Title, _ = tag["title"].(string)
+	t.Author, _ = tag["author"].(string)
+	return t, true
+}
+
+func (r *Report) WriteFile(filename string) error {
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
+
+// GenerateFile opens the world and writes its report to filename
+func GenerateFile(worldPath, name, filename string, br world.BlockRegistry) (*Report, error) {
+	w, err := mcworld.Open(worldPath, br)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+	r, err := Generate(w, name)
+	if err != nil {
+		return nil, err
+	}
+	return r, r.WriteFile(filename)
+}
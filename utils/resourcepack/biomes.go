@@ -0,0 +1,31 @@
+package resourcepack
+
+import (
+	"encoding/json"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/google/uuid"
+)
+
+// NewBiomes returns the companion pack with the water colours of the custom biomes of a server, the uuid is the same every time for the same server
+func NewBiomes(name string, waterColours map[string]string) *Pack {
+	p := &Pack{
+		Manifest: buildManifest(
+			uuid.MustParse(utils.RandSeededUUID(name+"_biomes")),
+			uuid.MustParse(utils.RandSeededUUID(name+"_biomes_module")),
+		),
+		Files: make(map[string][]byte),
+	}
+	p.Manifest.Header.Name = name + " biomes"
+	p.Manifest.Header.Description = "Biome colours of " + name
+	p.Manifest.Modules[0].Description = p.Manifest.Header.Description
+
+	biomes := make(map[string]any, len(waterColours))
+	for name, colour := range waterColours {
+		biomes[name] = map[string]any{
+			"water_surface_color": colour,
+		}
+	}
+	p.Files["biomes_client.json"], _ = json.MarshalIndent(map[string]any{"biomes": biomes}, "", "\t")
+	return p
+}
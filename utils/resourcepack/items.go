@@ -0,0 +1,188 @@
+package resourcepack
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// NewItems returns the companion pack for the custom items of a server, the uuid is the same every time for the same server
+func NewItems(name string) *Pack {
+	p := &Pack{
+		Manifest: buildManifest(
+			uuid.MustParse(utils.RandSeededUUID(name+"_items")),
+			uuid.MustParse(utils.RandSeededUUID(name+"_items_module")),
+		),
+		Files: make(map[string][]byte),
+	}
+	p.Manifest.Header.Name = name + " items"
+	p.Manifest.Header.Description = "Item textures and attachables of " + name
+	p.Manifest.Modules[0].Description = p.Manifest.Header.Description
+	return p
+}
+
+// copyTexture copies a texture path without extension, the client looks for png and tga
+func (p *Pack) copyTexture(rp *ReadablePack, texture string) bool {
+	for _, ext := range []string{".png", ".tga", ""} {
+		data, err := fs.ReadFile(rp.fsys, texture+ext)
+		if err == nil {
+			p.Files[texture+ext] = data
+			return true
+		}
+	}
+	return false
+}
+
+// findIcon resolves an icon name to item_texture.json entry, or to a texture file with that name
+func (p *Pack) findIcon(packs []*ReadablePack, icon string) (entry any, ok bool) {
+	for _, rp := range packs {
+		if entry, ok := rp.itemTextures[icon].(map[string]any); ok {
+			for _, texture := range rp.ItemTexturePaths(icon) {
+				p.copyTexture(rp, texture)
+			}
+			return entry, true
+		}
+	}
+	// the entry is missing but the texture may still be in a pack
+	for _, texture := range []string{icon, path.Join("textures/items", icon)} {
+		for _, rp := range packs {
+			if p.copyTexture(rp, texture) {
+				return map[string]any{"textures": texture}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+type clientDescription struct {
+	Identifier string            `json:"identifier"`
+	Textures   map[string]string `json:"textures"`
+	Geometry   map[string]string `json:"geometry"`
+}
+
+// geometryFiles maps geometry identifiers to the model files in a pack
+func (rp *ReadablePack) geometryFiles() map[string]string {
+	files := make(map[string]string)
+	for _, name := range rp.names {
+		if !strings.HasPrefix(name, "models/") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		var model map[string]json.RawMessage
+		if rp.readJSON(name, &model) != nil {
+			continue
+		}
+		var geometries []struct {
+			Description struct {
+				Identifier string `json:"identifier"`
+			} `json:"description"`
+		}
+		if json.Unmarshal(model["minecraft:geometry"], &geometries) == nil {
+			for _, g := range geometries {
+				files[g.Description.Identifier] = name
+			}
+		}
+		// the old format has the identifiers as keys, with an optional parent after a :
+		for key := range model {
+			if strings.HasPrefix(key, "geometry.") {
+				id, _, _ := strings.Cut(key, ":")
+				files[id] = name
+			}
+		}
+	}
+	return files
+}
+
+// copyClientFiles copies the files in dir like attachables or items that describe one of the items,
+// with their textures and geometry. forItem returns the item an identifier belongs to
+func (p *Pack) copyClientFiles(rp *ReadablePack, dir, key string, forItem func(string) bool) int {
+	var geometries map[string]string
+	count := 0
+	for _, name := range rp.names {
+		if !strings.HasPrefix(name, dir+"/") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		var file map[string]json.RawMessage
+		if rp.readJSON(name, &file) != nil {
+			continue
+		}
+		var content struct {
+			Description clientDescription `json:"description"`
+		}
+		if json.Unmarshal(file[key], &content) != nil || !forItem(content.Description.Identifier) {
+			continue
+		}
+		if _, ok := p.Files[name]; ok {
+			continue
+		}
+		data, err := fs.ReadFile(rp.fsys, name)
+		if err != nil {
+			continue
+		}
+		p.Files[name] = data
+		count++
+
+		for _, texture := range content.Description.Textures {
+			p.copyTexture(rp, texture)
+		}
+		if len(content.Description.Geometry) > 0 && geometries == nil {
+			geometries = rp.geometryFiles()
+		}
+		for _, geometry := range content.Description.Geometry {
+			if model, ok := geometries[geometry]; ok {
+				p.Files[model], _ = fs.ReadFile(rp.fsys, model)
+			}
+		}
+	}
+	return count
+}
+
+// AddItems adds the icons, attachables and client item files (with render offsets) of the custom items
+// from the server packs that can be read. icons maps the item identifiers to their icon name.
+// returns if anything was added
+func (p *Pack) AddItems(icons map[string]string, packs []utils.Pack) bool {
+	readable := OpenPacks(packs)
+
+	textureData := make(map[string]any)
+	for item, icon := range icons {
+		if icon == "" {
+			continue
+		}
+		if _, ok := textureData[icon]; ok {
+			continue
+		}
+		entry, ok := p.findIcon(readable, icon)
+		if !ok {
+			logrus.Warnf("no texture found for the icon %s of %s", icon, item)
+			continue
+		}
+		textureData[icon] = entry
+	}
+	if len(textureData) > 0 {
+		p.Files["textures/item_texture.json"], _ = json.MarshalIndent(map[string]any{
+			"resource_pack_name": "bedrocktool",
+			"texture_name":       "atlas.items",
+			"texture_data":       textureData,
+		}, "", "\t")
+	}
+
+	isItem := func(identifier string) bool {
+		// attachables for the player model are often named item.player
+		_, ok := icons[identifier]
+		if !ok {
+			_, ok = icons[strings.TrimSuffix(identifier, ".player")]
+		}
+		return ok
+	}
+	files := 0
+	for _, rp := range readable {
+		files += p.copyClientFiles(rp, "attachables", "minecraft:attachable", isItem)
+		files += p.copyClientFiles(rp, "items", "minecraft:item", isItem)
+	}
+
+	return len(textureData) > 0 || files > 0
+}
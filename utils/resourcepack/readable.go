@@ -0,0 +1,82 @@
+package resourcepack
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// ReadablePack is a server pack that could be opened, with its item textures read
+type ReadablePack struct {
+	Name  string
+	fsys  fs.FS
+	names []string
+
+	// from textures/item_texture.json
+	itemTextures map[string]any
+}
+
+// OpenPacks opens the packs that can be read in the same order, encrypted packs without a key are left out
+func OpenPacks(packs []utils.Pack) []*ReadablePack {
+	var out []*ReadablePack
+	for _, pack := range packs {
+		if pack.Base().Encrypted() && !pack.CanDecrypt() {
+			continue
+		}
+		fsys, names, err := pack.FS()
+		if err != nil {
+			logrus.Warnf("%s: %s", pack.Base().Name(), err)
+			continue
+		}
+		rp := &ReadablePack{Name: pack.Base().Name(), fsys: fsys, names: names}
+		var itemTexture struct {
+			TextureData map[string]any `json:"texture_data"`
+		}
+		if err := rp.readJSON("textures/item_texture.json", &itemTexture); err == nil {
+			rp.itemTextures = itemTexture.TextureData
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			logrus.Warnf("%s item_texture.json: %s", rp.Name, err)
+		}
+		out = append(out, rp)
+	}
+	return out
+}
+
+func (rp *ReadablePack) readJSON(name string, v any) error {
+	data, err := fs.ReadFile(rp.fsys, name)
+	if err != nil {
+		return err
+	}
+	return utils.ParseJson(data, v)
+}
+
+// ItemTexturePaths returns the texture paths of a name in item_texture.json, empty if the pack doesnt have it
+func (rp *ReadablePack) ItemTexturePaths(name string) []string {
+	entry, ok := rp.itemTextures[name].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return texturePaths(entry["textures"])
+}
+
+// texturePaths returns the paths in a textures entry of item_texture.json,
+// it can be a string, an object with path or a list of either
+func texturePaths(textures any) []string {
+	switch t := textures.(type) {
+	case string:
+		return []string{t}
+	case map[string]any:
+		if p, ok := t["path"].(string); ok {
+			return []string{p}
+		}
+	case []any:
+		var out []string
+		for _, e := range t {
+			out = append(out, texturePaths(e)...)
+		}
+		return out
+	}
+	return nil
+}
@@ -138,7 +138,7 @@ func (p *Pack) AddPlayer(id string, skinTexture *image.RGBA, capeTexture *image.
 		capeName = path.Join("textures", "player", "cape_"+capeID)
 
 		textureData := bytes.NewBuffer(nil)
-		png.Encode(textureData, skinTexture)
+		png.Encode(textureData, capeTexture)
 		p.Files[capeName+".png"] = textureData.Bytes()
 	}
 
@@ -0,0 +1,162 @@
+package serverdata
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// names of the argument types as the client shows them
+var commandArgTypeNames = map[uint32]string{
+	protocol.CommandArgTypeInt:             "int",
+	protocol.CommandArgTypeFloat:           "float",
+	protocol.CommandArgTypeValue:           "value",
+	protocol.CommandArgTypeWildcardInt:     "wildcard int",
+	protocol.CommandArgTypeOperator:        "operator",
+	protocol.CommandArgTypeCompareOperator: "compare operator",
+	protocol.CommandArgTypeTarget:          "target",
+	protocol.CommandArgTypeWildcardTarget:  "wildcard target",
+	protocol.CommandArgTypeFilepath:        "filepath",
+	protocol.CommandArgTypeIntegerRange:    "integer range",
+	protocol.CommandArgTypeEquipmentSlots:  "equipment slots",
+	protocol.CommandArgTypeString:          "string",
+	protocol.CommandArgTypeBlockPosition:   "x y z",
+	protocol.CommandArgTypePosition:        "x y z",
+	protocol.CommandArgTypeMessage:         "message",
+	protocol.CommandArgTypeRawText:         "text",
+	protocol.CommandArgTypeJSON:            "json",
+	protocol.CommandArgTypeBlockStates:     "block states",
+	protocol.CommandArgTypeCommand:         "command",
+}
+
+type CommandParam struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Optional bool     `json:"optional,omitempty"`
+	Values   []string `json:"values,omitempty"`
+}
+
+type Command struct {
+	Name            string           `json:"name"`
+	Description     string           `json:"description,omitempty"`
+	Aliases         []string         `json:"aliases,omitempty"`
+	PermissionLevel byte             `json:"permission_level"`
+	Overloads       [][]CommandParam `json:"overloads"`
+}
+
+func enumValues(pk *packet.AvailableCommands, enum protocol.CommandEnum) []string {
+	values := make([]string, 0, len(enum.ValueIndices))
+	for _, i := range enum.ValueIndices {
+		if int(i) < len(pk.EnumValues) {
+			values = append(values, pk.EnumValues[i])
+		}
+	}
+	return values
+}
+
+func commandParam(pk *packet.AvailableCommands, p protocol.CommandParameter) CommandParam {
+	param := CommandParam{Name: p.Name, Optional: p.Optional}
+	index := p.Type & 0xffff
+	switch {
+	case p.Type&protocol.CommandArgSoftEnum != 0:
+		if int(index) < len(pk.DynamicEnums) {
+			param.Type = pk.DynamicEnums[index].Type
+			param.Values = pk.DynamicEnums[index].Values
+		}
+	case p.Type&protocol.CommandArgEnum != 0:
+		if int(index) < len(pk.Enums) {
+			param.Type = pk.Enums[index].Type
+			param.Values = enumValues(pk, pk.Enums[index])
+		}
+	case p.Type&protocol.CommandArgSuffixed != 0:
+		if int(index) < len(pk.Suffixes) {
+			param.Type = "suffix " + pk.Suffixes[index]
+		}
+	default:
+		param.Type = commandArgTypeNames[index]
+	}
+	if param.Type == "" {
+		param.Type = fmt.Sprintf("unknown %#x", p.Type)
+	}
+	return param
+}
+
+// Commands converts AvailableCommands to a list sorted by name
+func Commands(pk *packet.AvailableCommands) []Command {
+	commands := make([]Command, 0, len(pk.Commands))
+	for _, c := range pk.Commands {
+		cmd := Command{
+			Name:            c.Name,
+			Description:     c.Description,
+			PermissionLevel: c.PermissionLevel,
+			Overloads:       [][]CommandParam{},
+		}
+		if int(c.AliasesOffset) < len(pk.Enums) {
+			for _, alias := range enumValues(pk, pk.Enums[c.AliasesOffset]) {
+				if alias != c.Name {
+					cmd.Aliases = append(cmd.Aliases, alias)
+				}
+			}
+		}
+		for _, overload := range c.Overloads {
+			params := make([]CommandParam, 0, len(overload.Parameters))
+			for _, p := range overload.Parameters {
+				params = append(params, commandParam(pk, p))
+			}
+			cmd.Overloads = append(cmd.Overloads, params)
+		}
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// usage is like the client shows it, /name <required: type> [optional: type]
+func usage(name string, params []CommandParam) string {
+	var b strings.Builder
+	b.WriteString("/" + name)
+	for _, p := range params {
+		t := p.Type
+		if len(p.Values) > 0 && len(p.Values) <= 8 {
+			t = strings.Join(p.Values, "|")
+		}
+		if p.Optional {
+			fmt.Fprintf(&b, " [%s: %s]", p.Name, t)
+		} else {
+			fmt.Fprintf(&b, " <%s: %s>", p.Name, t)
+		}
+	}
+	return b.String()
+}
+
+// WriteCommandsMarkdown writes a section with the usages of every command
+func WriteCommandsMarkdown(w io.Writer, commands []Command) error {
+	if _, err := fmt.Fprintf(w, "# Commands\n\n"); err != nil {
+		return err
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "## /%s\n\n", cmd.Name)
+		if cmd.Description != "" {
+			fmt.Fprintf(w, "%s\n\n", cmd.Description)
+		}
+		if len(cmd.Aliases) > 0 {
+			fmt.Fprintf(w, "Aliases: %s\n\n", strings.Join(cmd.Aliases, ", "))
+		}
+		fmt.Fprintf(w, "Permission level: %d\n\n", cmd.PermissionLevel)
+		overloads := cmd.Overloads
+		if len(overloads) == 0 {
+			overloads = [][]CommandParam{nil}
+		}
+		for _, params := range overloads {
+			if _, err := fmt.Fprintf(w, "- `%s`\n", usage(cmd.Name, params)); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
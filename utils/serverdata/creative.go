@@ -0,0 +1,68 @@
+package serverdata
+
+import (
+	"strings"
+
+	"github.com/bedrock-tool/bedrocktool/utils"
+	"github.com/bedrock-tool/bedrocktool/utils/resourcepack"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+type CreativeItem struct {
+	ID    uint32         `json:"id"`
+	Name  string         `json:"name"`
+	Meta  uint32         `json:"meta,omitempty"`
+	Count uint16         `json:"count"`
+	NBT   map[string]any `json:"nbt,omitempty"`
+	// texture path in the resource pack IconPack, empty when it wasnt found
+	Icon     string `json:"icon,omitempty"`
+	IconPack string `json:"icon_pack,omitempty"`
+}
+
+// Icons finds item textures by their name in item_texture.json of resource packs
+type Icons struct {
+	packs []*resourcepack.ReadablePack
+}
+
+// NewIcons reads the item textures of packs, earlier packs win like on the client
+func NewIcons(packs []utils.Pack) *Icons {
+	return &Icons{packs: resourcepack.OpenPacks(packs)}
+}
+
+// Find returns the pack and path of a texture name
+func (i *Icons) Find(name string) (pack, path string, ok bool) {
+	for _, rp := range i.packs {
+		if paths := rp.ItemTexturePaths(name); len(paths) > 0 {
+			return rp.Name, paths[0], true
+		}
+	}
+	return "", "", false
+}
+
+// Creative converts the items of CreativeContent, itemName looks up network ids and
+// iconName returns the icon texture name of an item, empty to use the name of the item
+func Creative(items []protocol.CreativeItem, itemName func(int32) (string, bool), iconName func(string) string, icons *Icons) []CreativeItem {
+	out := make([]CreativeItem, 0, len(items))
+	for _, ci := range items {
+		name, ok := itemName(ci.Item.NetworkID)
+		if !ok {
+			continue
+		}
+		it := CreativeItem{
+			ID:    ci.CreativeItemNetworkID,
+			Name:  name,
+			Meta:  ci.Item.MetadataValue,
+			Count: ci.Item.Count,
+			NBT:   ci.Item.NBTData,
+		}
+		texture := iconName(name)
+		if texture == "" {
+			_, texture, _ = strings.Cut(name, ":")
+		}
+		if icons != nil {
+			it.IconPack, it.Icon, _ = icons.Find(texture)
+		}
+		out = append(out, it)
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+// Package serverdata writes the creative inventory and the commands a server sends as files
+package serverdata
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const (
+	CreativeFile         = "creative.json"
+	CommandsFile         = "commands.json"
+	CommandsMarkdownFile = "commands.md"
+)
+
+func writeJSON(filename string, v any) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	e := json.NewEncoder(f)
+	e.SetIndent("", "\t")
+	return e.Encode(v)
+}
+
+// Write saves the creative items and commands to dir, empty ones are left out
+func Write(dir string, creative []CreativeItem, commands []Command) error {
+	if len(creative) == 0 && len(commands) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if len(creative) > 0 {
+		if err := writeJSON(filepath.Join(dir, CreativeFile), creative); err != nil {
+			return err
+		}
+	}
+	if len(commands) > 0 {
+		if err := writeJSON(filepath.Join(dir, CommandsFile), commands); err != nil {
+			return err
+		}
+		f, err := os.Create(filepath.Join(dir, CommandsMarkdownFile))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := WriteCommandsMarkdown(f, commands); err != nil {
+			return err
+		}
+	}
+	return nil
+}
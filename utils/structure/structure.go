@@ -0,0 +1,236 @@
+// Package structure writes .mcstructure files that can be loaded with structure blocks or /structure load
+package structure
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+// Source is what a structure is read from, a saved world or the world thats being captured
+type Source interface {
+	LoadChunk(pos world.ChunkPos) (*chunk.Chunk, bool, error)
+	BlockNBTs(pos world.ChunkPos) (map[cube.Pos]map[string]any, error)
+	Entities(pos world.ChunkPos) ([]map[string]any, error)
+}
+
+// exporting more than this would need gigabytes of memory
+const maxVolume = 64 * 384 * 64 * 16
+
+type Structure struct {
+	Origin cube.Pos
+	Size   [3]int
+
+	// index into the palette per block per layer, -1 for nothing
+	layers       [2][]int32
+	palette      []map[string]any
+	paletteIndex map[string]int32
+	positionData map[string]any
+	entities     []map[string]any
+}
+
+func New(origin cube.Pos, size [3]int) *Structure {
+	s := &Structure{
+		Origin:       origin,
+		Size:         size,
+		paletteIndex: make(map[string]int32),
+		positionData: make(map[string]any),
+	}
+	volume := size[0] * size[1] * size[2]
+	for i := range s.layers {
+		s.layers[i] = make([]int32, volume)
+		for j := range s.layers[i] {
+			s.layers[i][j] = -1
+		}
+	}
+	return s
+}
+
+// index of a position relative to the origin, z is the fastest changing axis
+func (s *Structure) index(pos cube.Pos) int {
+	return (pos[0]*s.Size[1]+pos[1])*s.Size[2] + pos[2]
+}
+
+// SetBlock sets the block at a position relative to the origin
+func (s *Structure) SetBlock(pos cube.Pos, layer int, name string, properties map[string]any) {
+	key := fmt.Sprintf("%s%v", name, properties)
+	idx, ok := s.paletteIndex[key]
+	if !ok {
+		if properties == nil {
+			properties = map[string]any{}
+		}
+		idx = int32(len(s.palette))
+		s.palette = append(s.palette, map[string]any{
+			"name":    name,
+			"states":  properties,
+			"version": chunk.CurrentBlockVersion,
+		})
+		s.paletteIndex[key] = idx
+	}
+	s.layers[layer][s.index(pos)] = idx
+}
+
+// SetBlockNBT sets the block entity at a position relative to the origin
+func (s *Structure) SetBlockNBT(pos cube.Pos, data map[string]any) {
+	s.positionData[strconv.Itoa(s.index(pos))] = map[string]any{
+		"block_entity_data": data,
+	}
+}
+
+// AddEntity adds an entity, its position stays in world coordinates
+func (s *Structure) AddEntity(data map[string]any) {
+	s.entities = append(s.entities, data)
+}
+
+func vec3i(v [3]int) []int32 {
+	return []int32{int32(v[0]), int32(v[1]), int32(v[2])}
+}
+
+func (s *Structure) NBT() map[string]any {
+	palette := s.palette
+	if palette == nil {
+		palette = []map[string]any{}
+	}
+	entities := s.entities
+	if entities == nil {
+		entities = []map[string]any{}
+	}
+	return map[string]any{
+		"format_version":         int32(1),
+		"size":                   vec3i(s.Size),
+		"structure_world_origin": vec3i(s.Origin),
+		"structure": map[string]any{
+			"block_indices": []any{s.layers[0], s.layers[1]},
+			"entities":      entities,
+			"palette": map[string]any{
+				"default": map[string]any{
+					"block_palette":       palette,
+					"block_position_data": s.positionData,
+				},
+			},
+		},
+	}
+}
+
+func (s *Structure) WriteFile(filename string) error {
+	data, err := nbt.MarshalEncoding(s.NBT(), nbt.LittleEndian)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
+
+// entityPos reads Pos, which is []any when it was decoded from disk
+func entityPos(e map[string]any) []float32 {
+	switch pos := e["Pos"].(type) {
+	case []float32:
+		return pos
+	case []any:
+		out := make([]float32, len(pos))
+		for i, v := range pos {
+			out[i], _ = v.(float32)
+		}
+		return out
+	}
+	return nil
+}
+
+// inBox checks if an entity position is in the box, both corners are inclusive
+func inBox(pos []float32, lo, hi cube.Pos) bool {
+	if len(pos) != 3 {
+		return false
+	}
+	for i := 0; i < 3; i++ {
+		v := int(math.Floor(float64(pos[i])))
+		if v < lo[i] || v > hi[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Export reads all blocks, block entities and entities between the two corners from src
+func Export(src Source, a, b cube.Pos) (*Structure, error) {
+	lo := cube.Pos{min(a[0], b[0]), min(a[1], b[1]), min(a[2], b[2])}
+	hi := cube.Pos{max(a[0], b[0]), max(a[1], b[1]), max(a[2], b[2])}
+	size := [3]int{hi[0] - lo[0] + 1, hi[1] - lo[1] + 1, hi[2] - lo[2] + 1}
+	if size[0]*size[1]*size[2] > maxVolume {
+		return nil, errors.New("structure too large")
+	}
+	s := New(lo, size)
+
+	for cx := lo[0] >> 4; cx <= hi[0]>>4; cx++ {
+		for cz := lo[2] >> 4; cz <= hi[2]>>4; cz++ {
+			cp := world.ChunkPos{int32(cx), int32(cz)}
+			ch, found, err := src.LoadChunk(cp)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				s.addChunk(cp, ch, lo, hi)
+			}
+
+			blockNBTs, err := src.BlockNBTs(cp)
+			if err != nil {
+				return nil, err
+			}
+			for pos, data := range blockNBTs {
+				if pos.X() < lo[0] || pos.Y() < lo[1] || pos.Z() < lo[2] ||
+					pos.X() > hi[0] || pos.Y() > hi[1] || pos.Z() > hi[2] {
+					continue
+				}
+				s.SetBlockNBT(pos.Sub(lo), data)
+			}
+
+			entities, err := src.Entities(cp)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entities {
+				if inBox(entityPos(e), lo, hi) {
+					s.AddEntity(e)
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *Structure) addChunk(cp world.ChunkPos, ch *chunk.Chunk, lo, hi cube.Pos) {
+	br := ch.BlockRegistry.(world.BlockRegistry)
+	r := ch.Range()
+	for x := 0; x < 16; x++ {
+		wx := int(cp[0])<<4 + x
+		if wx < lo[0] || wx > hi[0] {
+			continue
+		}
+		for z := 0; z < 16; z++ {
+			wz := int(cp[1])<<4 + z
+			if wz < lo[2] || wz > hi[2] {
+				continue
+			}
+			for y := max(lo[1], r.Min()); y <= min(hi[1], r.Max()); y++ {
+				pos := cube.Pos{wx, y, wz}.Sub(lo)
+				for layer := uint8(0); layer < 2; layer++ {
+					b, found := br.BlockByRuntimeID(ch.Block(uint8(x), int16(y), uint8(z), layer))
+					if !found {
+						continue
+					}
+					name, properties := b.EncodeBlock()
+					// an empty second layer is not stored
+					if layer == 1 && name == "minecraft:air" {
+						continue
+					}
+					s.SetBlock(pos, int(layer), name, properties)
+				}
+			}
+		}
+	}
+}
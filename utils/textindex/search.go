@@ -0,0 +1,83 @@
+package textindex
+
+import (
+	"archive/zip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Search calls fn for every entry that matches, in the world folders and .mcworld files under dir.
+// a .mcworld is only read when its folder is gone, deduplicated worlds keep their index next to them as <world>.text-index.jsonl
+func Search(dir string, match func(Entry) bool, fn func(world string, e Entry)) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		var entries []Entry
+		var worldPath string
+		switch {
+		case !d.IsDir() && d.Name() == Filename:
+			worldPath = filepath.Dir(p)
+			entries, err = readFile(p)
+			if err != nil {
+				return err
+			}
+		case !d.IsDir() && strings.HasSuffix(d.Name(), "."+Filename):
+			worldPath = strings.TrimSuffix(p, "."+Filename)
+			entries, err = readFile(p)
+			if err != nil {
+				return err
+			}
+		case !d.IsDir() && strings.HasSuffix(p, ".mcworld"):
+			worldPath = strings.TrimSuffix(p, ".mcworld")
+			if _, err := os.Stat(filepath.Join(worldPath, Filename)); err == nil {
+				return nil
+			}
+			if _, err := os.Stat(worldPath + "." + Filename); err == nil {
+				return nil
+			}
+			entries, err = readZip(p)
+			if err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, worldPath)
+		if err != nil {
+			rel = worldPath
+		}
+		for _, e := range entries {
+			if match(e) {
+				fn(rel, e)
+			}
+		}
+		return nil
+	})
+}
+
+func readFile(filename string) ([]Entry, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+func readZip(filename string) ([]Entry, error) {
+	z, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	f, err := z.Open(Filename)
+	if err != nil {
+		return nil, nil
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,248 @@
+// Package textindex collects the text players can read in a world, signs, books,
+// command blocks and item names, so it can be searched later
+package textindex
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// Filename is the name of the index in the world folder
+const Filename = "text-index.jsonl"
+
+const (
+	TypeSign          = "sign"
+	TypeBook          = "book"
+	TypeLectern       = "lectern"
+	TypeCommand       = "command"
+	TypeContainerName = "container_name"
+	TypeItemName      = "item_name"
+	TypeItemLore      = "item_lore"
+)
+
+type Entry struct {
+	Type      string `json:"type"`
+	Dimension string `json:"dimension"`
+	Pos       [3]int `json:"pos"`
+	Text      string `json:"text"`
+	// block entity id or item name the text is from
+	Source string `json:"source,omitempty"`
+}
+
+type key struct {
+	dim       string
+	pos       cube.Pos
+	container bool
+}
+
+// Index holds the entries by position, setting a position again replaces what was there
+type Index struct {
+	l       sync.Mutex
+	entries map[key][]Entry
+}
+
+func New() *Index {
+	return &Index{entries: make(map[key][]Entry)}
+}
+
+func (idx *Index) set(k key, entries []Entry) {
+	idx.l.Lock()
+	defer idx.l.Unlock()
+	if len(entries) == 0 {
+		delete(idx.entries, k)
+		return
+	}
+	idx.entries[k] = entries
+}
+
+// SetBlock indexes the text of a block entity
+func (idx *Index) SetBlock(dim world.Dimension, pos cube.Pos, m map[string]any) {
+	d := fmt.Sprint(dim)
+	add := func(entries []Entry, typ, text, source string) []Entry {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return entries
+		}
+		return append(entries, Entry{Type: typ, Dimension: d, Pos: [3]int(pos), Text: text, Source: source})
+	}
+
+	var entries []Entry
+	id, _ := m["id"].(string)
+	switch id {
+	case "Sign", "HangingSign":
+		for _, side := range []string{"FrontText", "BackText"} {
+			if s, ok := m[side].(map[string]any); ok {
+				text, _ := s["Text"].(string)
+				entries = add(entries, TypeSign, text, id)
+			}
+		}
+		// signs before 1.20
+		if _, ok := m["FrontText"]; !ok {
+			text, _ := m["Text"].(string)
+			entries = add(entries, TypeSign, text, id)
+		}
+	case "Lectern":
+		if book, ok := m["book"].(map[string]any); ok {
+			name, _ := book["Name"].(string)
+			tag, _ := book["tag"].(map[string]any)
+			entries = add(entries, TypeLectern, bookText(tag), name)
+		}
+	case "CommandBlock":
+		command, _ := m["Command"].(string)
+		entries = add(entries, TypeCommand, command, id)
+	}
+	if name, ok := m["CustomName"].(string); ok {
+		entries = add(entries, TypeContainerName, name, id)
+	}
+	idx.set(key{dim: d, pos: pos}, entries)
+}
+
+// SetContainer indexes the items of a container, items are nbt compounds with Name and tag
+func (idx *Index) SetContainer(dim world.Dimension, pos cube.Pos, items []map[string]any) {
+	d := fmt.Sprint(dim)
+	var entries []Entry
+	for _, it := range items {
+		name, _ := it["Name"].(string)
+		tag, _ := it["tag"].(map[string]any)
+		for _, e := range itemEntries(tag) {
+			e.Dimension = d
+			e.Pos = [3]int(pos)
+			e.Source = name
+			entries = append(entries, e)
+		}
+	}
+	idx.set(key{dim: d, pos: pos, container: true}, entries)
+}
+
+func itemEntries(tag map[string]any) []Entry {
+	if tag == nil {
+		return nil
+	}
+	var entries []Entry
+	if display, ok := tag["display"].(map[string]any); ok {
+		if name, _ := display["Name"].(string); strings.TrimSpace(name) != "" {
+			entries = append(entries, Entry{Type: TypeItemName, Text: strings.TrimSpace(name)})
+		}
+		var lore []string
+		switch l := display["Lore"].(type) {
+		case []string:
+			lore = l
+		case []any:
+			for _, line := range l {
+				if s, ok := line.(string); ok {
+					lore = append(lore, s)
+				}
+			}
+		}
+		if text := strings.TrimSpace(strings.Join(lore, "\n")); text != "" {
+			entries = append(entries, Entry{Type: TypeItemLore, Text: text})
+		}
+	}
+	if text := bookText(tag); text != "" {
+		entries = append(entries, Entry{Type: TypeBook, Text: text})
+	}
+	return entries
+}
+
+// bookText joins the pages of a book, with the title and author of written books in front
+func bookText(tag map[string]any) string {
+	if tag == nil {
+		return ""
+	}
+	var parts []string
+	title, _ := tag["title"].(string)
+	author, _ := tag["author"].(string)
+	if title != "" {
+		parts = append(parts, title+" by "+author)
+	}
+	var pages []any
+	switch p := tag["pages"].(type) {
+	case []any:
+		pages = p
+	case []map[string]any:
+		for _, page := range p {
+			pages = append(pages, page)
+		}
+	}
+	for _, p := range pages {
+		page, _ := p.(map[string]any)
+		if text, _ := page["text"].(string); strings.TrimSpace(text) != "" {
+			parts = append(parts, strings.TrimSpace(text))
+		}
+	}
+	if len(parts) == 0 || (title != "" && len(parts) == 1) {
+		return ""
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Entries returns all entries sorted by dimension and position
+func (idx *Index) Entries() []Entry {
+	idx.l.Lock()
+	var out []Entry
+	for _, entries := range idx.entries {
+		out = append(out, entries...)
+	}
+	idx.l.Unlock()
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+		for n := 0; n < 3; n++ {
+			if a.Pos[n] != b.Pos[n] {
+				return a.Pos[n] < b.Pos[n]
+			}
+		}
+		return a.Type < b.Type
+	})
+	return out
+}
+
+// WriteFile writes one json entry per line, nothing is written for an empty index
+func (idx *Index) WriteFile(filename string) error {
+	entries := idx.Entries()
+	if len(entries) == 0 {
+		return nil
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Read reads the entries of a text-index.jsonl
+func Read(r io.Reader) ([]Entry, error) {
+	var out []Entry
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return out, err
+		}
+		out = append(out, e)
+	}
+	return out, s.Err()
+}
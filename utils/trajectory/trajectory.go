@@ -0,0 +1,207 @@
+// Package trajectory records where entities moved over time, for looking at mob farms and player routes
+package trajectory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatGeoJSON = "geojson"
+)
+
+// Point is one known position of an entity, Time is in unix milliseconds
+type Point struct {
+	Time     int64
+	Position mgl32.Vec3
+	Velocity mgl32.Vec3
+}
+
+type Track struct {
+	UniqueID   int64
+	EntityType string
+	// name tag or player name
+	Name   string
+	Points []Point
+}
+
+// Recorder collects the tracks of the entity types it is set to record
+type Recorder struct {
+	l      sync.Mutex
+	types  map[string]bool
+	tracks map[int64]*Track
+}
+
+// NewRecorder records the entity types, all entities if types is empty.
+// types without a namespace are in minecraft:
+func NewRecorder(types []string) *Recorder {
+	r := &Recorder{
+		tracks: make(map[int64]*Track),
+	}
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !strings.Contains(t, ":") {
+			t = "minecraft:" + t
+		}
+		if r.types == nil {
+			r.types = make(map[string]bool)
+		}
+		r.types[t] = true
+	}
+	return r
+}
+
+// Records reports if entities of this type are recorded
+func (r *Recorder) Records(entityType string) bool {
+	return r.types == nil || r.types[entityType]
+}
+
+func (r *Recorder) track(uniqueID int64, entityType, name string) *Track {
+	t, ok := r.tracks[uniqueID]
+	if !ok {
+		t = &Track{UniqueID: uniqueID, EntityType: entityType}
+		r.tracks[uniqueID] = t
+	}
+	if name != "" {
+		t.Name = name
+	}
+	return t
+}
+
+// Move adds a position, positions that did not change are skipped
+func (r *Recorder) Move(uniqueID int64, entityType, name string, pos, velocity mgl32.Vec3, at time.Time) {
+	if !r.Records(entityType) {
+		return
+	}
+	r.l.Lock()
+	defer r.l.Unlock()
+	t := r.track(uniqueID, entityType, name)
+	if n := len(t.Points); n > 0 && t.Points[n-1].Position == pos && t.Points[n-1].Velocity == velocity {
+		return
+	}
+	t.Points = append(t.Points, Point{Time: at.UnixMilli(), Position: pos, Velocity: velocity})
+}
+
+// Tracks returns the tracks with at least one point sorted by unique id
+func (r *Recorder) Tracks() []Track {
+	r.l.Lock()
+	defer r.l.Unlock()
+	out := make([]Track, 0, len(r.tracks))
+	for _, t := range r.tracks {
+		if len(t.Points) == 0 {
+			continue
+		}
+		track := *t
+		track.Points = append([]Point(nil), t.Points...)
+		out = append(out, track)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UniqueID < out[j].UniqueID })
+	return out
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
+
+// WriteCSV writes one row per point
+func (r *Recorder) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"unique_id", "entity_type", "name", "time", "x", "y", "z", "vx", "vy", "vz"})
+	if err != nil {
+		return err
+	}
+	for _, t := range r.Tracks() {
+		for _, p := range t.Points {
+			err := cw.Write([]string{
+				strconv.FormatInt(t.UniqueID, 10), t.EntityType, t.Name,
+				time.UnixMilli(p.Time).UTC().Format(time.RFC3339Nano),
+				formatFloat(p.Position[0]), formatFloat(p.Position[1]), formatFloat(p.Position[2]),
+				formatFloat(p.Velocity[0]), formatFloat(p.Velocity[1]), formatFloat(p.Velocity[2]),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type geoJSONFeature struct {
+	Type     string `json:"type"`
+	Geometry struct {
+		Type        string       `json:"type"`
+		Coordinates [][3]float32 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		UniqueID   int64   `json:"unique_id"`
+		EntityType string  `json:"entity_type"`
+		Name       string  `json:"name,omitempty"`
+		Times      []int64 `json:"times"`
+	} `json:"properties"`
+}
+
+// WriteGeoJSON writes a FeatureCollection with a LineString for every entity.
+// coordinates are block coordinates as [x, z, y] so the map is seen from above
+func (r *Recorder) WriteGeoJSON(w io.Writer) error {
+	collection := struct {
+		Type     string           `json:"type"`
+		Features []geoJSONFeature `json:"features"`
+	}{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+
+	for _, t := range r.Tracks() {
+		var f geoJSONFeature
+		f.Type = "Feature"
+		f.Geometry.Type = "LineString"
+		f.Properties.UniqueID = t.UniqueID
+		f.Properties.EntityType = t.EntityType
+		f.Properties.Name = t.Name
+		for _, p := range t.Points {
+			f.Geometry.Coordinates = append(f.Geometry.Coordinates, [3]float32{p.Position[0], p.Position[2], p.Position[1]})
+			f.Properties.Times = append(f.Properties.Times, p.Time)
+		}
+		// a LineString needs two positions
+		if len(t.Points) == 1 {
+			f.Geometry.Coordinates = append(f.Geometry.Coordinates, f.Geometry.Coordinates[0])
+		}
+		collection.Features = append(collection.Features, f)
+	}
+	e := json.NewEncoder(w)
+	return e.Encode(collection)
+}
+
+// WriteFile writes the tracks in format, nothing is written when no entity moved
+func (r *Recorder) WriteFile(filename, format string) error {
+	var write func(io.Writer) error
+	switch format {
+	case FormatCSV:
+		write = r.WriteCSV
+	case FormatGeoJSON:
+		write = r.WriteGeoJSON
+	default:
+		return fmt.Errorf("unknown trajectory format %q", format)
+	}
+	if len(r.Tracks()) == 0 {
+		return nil
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return write(f)
+}
@@ -0,0 +1,205 @@
+// Package worldmeta is the bedrocktool.json saved in every world, what server it is from,
+// when and how it was captured, so an archive of worlds can be listed and filtered
+package worldmeta
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bedrock-tool/bedrocktool/utils/report"
+	"github.com/sirupsen/logrus"
+)
+
+// Filename is the name of the metadata in the world folder
+const Filename = "bedrocktool.json"
+
+type Server struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+	RealmID string `json:"realm_id,omitempty"`
+}
+
+type Pack struct {
+	Name      string `json:"name"`
+	UUID      string `json:"uuid"`
+	Version   [3]int `json:"version"`
+	Encrypted bool   `json:"encrypted,omitempty"`
+}
+
+type Dimension struct {
+	Chunks   int           `json:"chunks"`
+	Bounds   report.Bounds `json:"bounds"`
+	Entities int           `json:"entities"`
+}
+
+type Settings struct {
+	Void         bool     `json:"void"`
+	ExcludedMobs []string `json:"excluded_mobs,omitempty"`
+	Scripts      []string `json:"scripts,omitempty"`
+	// sha256 of the script files
+	ScriptHash string `json:"script_hash,omitempty"`
+}
+
+type Metadata struct {
+	Name   string `json:"name"`
+	Server Server `json:"server"`
+
+	CaptureStart time.Time `json:"capture_start"`
+	CaptureEnd   time.Time `json:"capture_end"`
+
+	// protocol and version of bedrocktool, GameVersion is the one the server says it runs
+	Protocol        int32  `json:"protocol"`
+	ProtocolVersion string `json:"protocol_version"`
+	GameVersion     string `json:"game_version,omitempty"`
+
+	// the ids the custom biomes have in the saved chunks. the game gives custom biomes
+	// its own ids when it loads the world, so this is the only record of which is which
+	CustomBiomes map[string]int `json:"custom_biomes,omitempty"`
+
+	Packs      []Pack               `json:"packs"`
+	Dimensions map[string]Dimension `json:"dimensions"`
+	Entities   int                  `json:"entities"`
+	Settings   Settings             `json:"settings"`
+}
+
+// AddReport fills in the chunks, bounds and entities from the world report
+func (m *Metadata) AddReport(r *report.Report) {
+	m.Dimensions = make(map[string]Dimension, len(r.Dimensions))
+	m.Entities = 0
+	for name, dim := range r.Dimensions {
+		var entities int
+		for _, census := range dim.Entities {
+			entities += census.Count
+		}
+		m.Dimensions[name] = Dimension{
+			Chunks:   dim.Chunks,
+			Bounds:   dim.Bounds,
+			Entities: entities,
+		}
+		m.Entities += entities
+	}
+}
+
+// Chunks is the chunk count of all dimensions
+func (m *Metadata) Chunks() int {
+	var chunks int
+	for _, dim := range m.Dimensions {
+		chunks += dim.Chunks
+	}
+	return chunks
+}
+
+// WriteFile writes the metadata to the world folder
+func (m *Metadata) WriteFile(worldFolder string) error {
+	f, err := os.Create(filepath.Join(worldFolder, Filename))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	e := json.NewEncoder(f)
+	e.SetIndent("", "\t")
+	return e.Encode(m)
+}
+
+func Read(r io.Reader) (*Metadata, error) {
+	var m Metadata
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func readFile(filename string) (*Metadata, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// readZip reads the metadata in a .mcworld, nil if it has none
+func readZip(filename string) (*Metadata, error) {
+	z, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	f, err := z.Open(Filename)
+	if err != nil {
+		return nil, nil
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Scan calls fn for every world in dir that has metadata, with the path of the world relative to dir.
+// a world folder is not walked into once its metadata is read. worlds that are both a folder and a .mcworld
+// are only reported once, zipped worlds are reported with their .mcworld path.
+// a <world>.bedrocktool.json next to a .mcworld wins over the one inside it.
+// worlds that cant be read are logged and skipped
+func Scan(dir string, fn func(world string, m *Metadata)) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			logrus.Warnf("worlds: %s", err)
+			return nil
+		}
+		var m *Metadata
+		var worldPath string
+		var skip error
+		switch {
+		case d.IsDir():
+			filename := filepath.Join(p, Filename)
+			if _, err := os.Stat(filename); err != nil {
+				return nil
+			}
+			// the rest of the folder is the world itself, db/ alone can be thousands of files
+			worldPath = p
+			skip = fs.SkipDir
+			m, err = readFile(filename)
+			p = filename
+		case strings.HasSuffix(d.Name(), "."+Filename):
+			// deduplicated worlds keep their metadata next to them
+			worldPath = strings.TrimSuffix(p, "."+Filename)
+			if _, err := os.Stat(worldPath + ".mcworld"); err == nil {
+				worldPath += ".mcworld"
+			}
+			m, err = readFile(p)
+		case strings.HasSuffix(p, ".mcworld"):
+			name := strings.TrimSuffix(p, ".mcworld")
+			if _, err := os.Stat(filepath.Join(name, Filename)); err == nil {
+				return nil
+			}
+			if _, err := os.Stat(name + "." + Filename); err == nil {
+				return nil
+			}
+			worldPath = p
+			m, err = readZip(p)
+		default:
+			return nil
+		}
+		if err != nil {
+			logrus.Warnf("worlds: %s: %s", p, err)
+			return skip
+		}
+		if m == nil {
+			return skip
+		}
+
+		rel, err := filepath.Rel(dir, worldPath)
+		if err != nil {
+			rel = worldPath
+		}
+		fn(rel, m)
+		return skip
+	})
+}